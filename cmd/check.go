@@ -52,10 +52,18 @@ var checkCmd = &cobra.Command{
 			return err
 		}
 
+		if err := c.LoadLinkedNamesFile(filepath.Dir(args[0])); err != nil {
+			return err
+		}
+
 		if err := c.CheckSyntax(); err != nil {
 			return err
 		}
 
+		for _, w := range c.Warnings() {
+			log.Warn().Msg(w)
+		}
+
 		log.Info().Msg(fmt.Sprintf("the workflow file %s syntax is ok", args[0]))
 
 		return nil
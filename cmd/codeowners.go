@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/k1LoW/ghdag/gh"
+	"github.com/spf13/cobra"
+)
+
+var ref string
+
+// codeownersCmd represents the codeowners command
+var codeownersCmd = &cobra.Command{
+	Use:   "codeowners [PATH...]",
+	Short: "Show the code owners of the paths",
+	Long:  `Show the code owners of the paths and the matching rules of the CODEOWNERS file.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		c, err := gh.NewClient()
+		if err != nil {
+			return err
+		}
+		co, err := c.GetCodeOwners(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if co == nil {
+			return errors.New("not found CODEOWNERS file")
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "# %s\n", co.Path)
+		for _, p := range args {
+			rule, ok := co.Rule(strings.TrimPrefix(p, "/"))
+			if !ok {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t-\t-\n", p)
+				continue
+			}
+			owners := []string{}
+			for _, o := range rule.Owners {
+				if strings.Contains(o, "@") {
+					// email
+					owners = append(owners, o)
+					continue
+				}
+				owners = append(owners, fmt.Sprintf("@%s", o))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", p, strings.Join(owners, " "), rule.Pattern)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(codeownersCmd)
+	codeownersCmd.Flags().StringVarP(&ref, "ref", "r", "", "branch, tag or commit SHA to read the CODEOWNERS file from ( default: the default branch )")
+}
@@ -49,6 +49,7 @@ func init() {
 	doCmd.AddCommand(doCommentCmd)
 	doCmd.AddCommand(doStateCmd)
 	doCmd.AddCommand(doNotifyCmd)
+	doCmd.AddCommand(doReactionCmd)
 }
 
 func initRunnerAndTask(ctx context.Context, number int) (*runner.Runner, *target.Target, error) {
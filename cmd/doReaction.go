@@ -0,0 +1,52 @@
+/*
+Copyright © 2021 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// doReactionCmd represents the doReaction command
+var doReactionCmd = &cobra.Command{
+	Use:   "reaction [REACTION]",
+	Short: "Add the reaction to the target issue or pull request (or the comment that triggered the event)",
+	Long:  "Add the reaction to the target issue or pull request (or the comment that triggered the event).",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reaction := args[0]
+		ctx := context.Background()
+		r, t, err := initRunnerAndTask(ctx, number)
+		if err != nil {
+			return err
+		}
+		if err := r.PerformReactionAction(ctx, t, reaction); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	doReactionCmd.Flags().IntVarP(&number, "number", "n", 0, "issue or pull request number")
+}
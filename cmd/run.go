@@ -29,6 +29,7 @@ import (
 
 	"github.com/goccy/go-yaml"
 	"github.com/k1LoW/ghdag/config"
+	"github.com/k1LoW/ghdag/erro"
 	"github.com/k1LoW/ghdag/runner"
 	"github.com/k1LoW/ghdag/version"
 	"github.com/pkg/errors"
@@ -54,6 +55,10 @@ var runCmd = &cobra.Command{
 			return err
 		}
 
+		if err := c.LoadLinkedNamesFile(filepath.Dir(args[0])); err != nil {
+			return err
+		}
+
 		if err := c.CheckSyntax(); err != nil {
 			return err
 		}
@@ -62,10 +67,16 @@ var runCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if cmd.Flags().Changed("seed") {
+			r.SetSeed(seed)
+		}
 
 		ctx := context.Background()
 
 		if err := r.Run(ctx); err != nil {
+			if errors.As(err, &erro.FailedError{}) && !failOnError {
+				return nil
+			}
 			return err
 		}
 
@@ -73,6 +84,13 @@ var runCmd = &cobra.Command{
 	},
 }
 
+var (
+	failOnError bool
+	seed        int64
+)
+
 func init() {
 	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().BoolVarP(&failOnError, "fail-on-error", "", true, "exit with non-zero status if any action of the tasks failed ( --fail-on-error=false to exit with zero status )")
+	runCmd.Flags().Int64VarP(&seed, "seed", "", 0, "seed for sampling reviewers and assignees ( to reproduce a session )")
 }
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/k1LoW/ghdag/env"
@@ -13,12 +15,31 @@ type Config struct {
 	Tasks       task.Tasks       `yaml:"tasks"`
 	Env         env.Env          `yaml:"env"`
 	LinkedNames name.LinkedNames `yaml:"linkedNames"`
+	// LinkedNamesFile is a path of YAML or CSV file of linked names ( relative to the workflow file )
+	LinkedNamesFile string `yaml:"linkedNamesFile,omitempty"`
 }
 
 func New() *Config {
 	return &Config{}
 }
 
+// LoadLinkedNamesFile loads linked names from `linkedNamesFile:` and appends them to `linkedNames:`
+func (c *Config) LoadLinkedNamesFile(base string) error {
+	if c.LinkedNamesFile == "" {
+		return nil
+	}
+	p := c.LinkedNamesFile
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(base, p)
+	}
+	l, err := name.Load(p)
+	if err != nil {
+		return err
+	}
+	c.LinkedNames = append(c.LinkedNames, l...)
+	return nil
+}
+
 func (c *Config) CheckSyntax() error {
 	valid := true
 	errors := []string{}
@@ -35,3 +56,20 @@ func (c *Config) CheckSyntax() error {
 	}
 	return nil
 }
+
+// Warnings returns the problems of the config that do not make the syntax invalid
+func (c *Config) Warnings() []string {
+	warnings := []string{}
+	if c.Tasks.UsesProjectVariables() && !c.getenvAsBool("GHDAG_FETCH_PROJECT_ITEMS") {
+		warnings = append(warnings, "`project_*` variables are used in `if:` but GHDAG_FETCH_PROJECT_ITEMS is not enabled, so they are always empty")
+	}
+	return warnings
+}
+
+// getenvAsBool returns the value of the environment variable as bool ( `env:` of the config takes precedence )
+func (c *Config) getenvAsBool(k string) bool {
+	if v, ok := c.Env[k]; ok {
+		return env.ParseBool(os.ExpandEnv(v))
+	}
+	return env.GetenvAsBool(k)
+}
@@ -90,7 +90,12 @@ func EnvMap() map[string]string {
 }
 
 func GetenvAsBool(k string) bool {
-	if os.Getenv(k) == "" || strings.ToLower(os.Getenv(k)) == "false" || os.Getenv(k) == "0" {
+	return ParseBool(os.Getenv(k))
+}
+
+// ParseBool returns false if the value is empty, `false` or `0`, otherwise true
+func ParseBool(v string) bool {
+	if v == "" || strings.ToLower(v) == "false" || v == "0" {
 		return false
 	}
 	return true
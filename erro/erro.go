@@ -44,3 +44,18 @@ func NewNoReviewerError(err error) NoReviewerError {
 		err: err,
 	}
 }
+
+type FailedError struct {
+	err error
+}
+
+func (e FailedError) Error() string {
+	return e.err.Error()
+}
+
+// NewFailedError ...
+func NewFailedError(err error) FailedError {
+	return FailedError{
+		err: err,
+	}
+}
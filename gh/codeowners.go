@@ -0,0 +1,235 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/hairyhenderson/go-codeowners"
+	"github.com/k1LoW/ghdag/target"
+	"github.com/rs/zerolog/log"
+	"github.com/shurcooL/githubv4"
+)
+
+// codeOwnersPaths are the locations of the CODEOWNERS file in the order GitHub looks for it
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners is a parsed CODEOWNERS file
+type CodeOwners struct {
+	Path string
+	d    *codeowners.Codeowners
+}
+
+// Rule returns the rule of the CODEOWNERS file that owns the path
+func (co *CodeOwners) Rule(path string) (*target.CodeOwnerRule, bool) {
+	rules := matchCodeOwnerRules(co.d, []string{path})
+	if len(rules) == 0 {
+		return nil, false
+	}
+	return rules[0], true
+}
+
+// GetCodeOwners returns the CODEOWNERS file of the ref ( the default branch if ref is empty ).
+// The parsed file is cached per ref. If there is no CODEOWNERS file, it returns nil.
+func (c *Client) GetCodeOwners(ctx context.Context, ref string) (*CodeOwners, error) {
+	if co, ok := c.codeOwners[ref]; ok {
+		return co, nil
+	}
+	var co *CodeOwners
+	for _, path := range codeOwnersPaths {
+		f, _, res, err := c.v3.Repositories.GetContents(ctx, c.owner, c.repo, path, &github.RepositoryContentGetOptions{
+			Ref: ref,
+		})
+		if err != nil {
+			if res != nil && res.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return nil, err
+		}
+		if f == nil {
+			// directory
+			continue
+		}
+		cc, err := f.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		d, err := codeowners.FromReader(strings.NewReader(cc), ".")
+		if err != nil {
+			return nil, err
+		}
+		co = &CodeOwners{Path: path, d: d}
+		break
+	}
+	if c.codeOwners == nil {
+		c.codeOwners = map[string]*CodeOwners{}
+	}
+	c.codeOwners[ref] = co
+	return co, nil
+}
+
+// codeOwnersRef returns the ref to read the CODEOWNERS file of the pull request from.
+// The head is resolved to the commit SHA, so the cache of GetCodeOwners hits as long as the head is not updated
+// ( ex. the target is fetched again for the tasks called via `next:` ).
+func codeOwnersRef(p pullRequestNode) (string, error) {
+	switch v := os.Getenv("GHDAG_CODEOWNERS_REF"); v {
+	case "head", "":
+		if p.HeadRefOid != "" {
+			return string(p.HeadRefOid), nil
+		}
+		return string(p.HeadRefName), nil
+	case "base":
+		return string(p.BaseRefName), nil
+	default:
+		return "", fmt.Errorf("invalid GHDAG_CODEOWNERS_REF: %s", v)
+	}
+}
+
+// getCodeOwnerRules returns the rules of the CODEOWNERS file that match the changed files of the pull request
+func (c *Client) getCodeOwnerRules(ctx context.Context, p pullRequestNode) ([]*target.CodeOwnerRule, error) {
+	ref, err := codeOwnersRef(p)
+	if err != nil {
+		return nil, err
+	}
+	co, err := c.GetCodeOwners(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if co == nil {
+		return []*target.CodeOwnerRule{}, nil
+	}
+
+	var cursor string
+	paths := []string{}
+
+	var q struct {
+		Repogitory struct {
+			PullRequest pullRequestFilesNode `graphql:"pullRequest(number: $number)"`
+		} `graphql:"repository(owner: $owner, name: $repo)"`
+	}
+
+	for {
+		variables := map[string]interface{}{
+			"owner":  githubv4.String(c.owner),
+			"repo":   githubv4.String(c.repo),
+			"number": p.Number,
+			"limit":  githubv4.Int(limit),
+			"cursor": githubv4.String(cursor),
+		}
+		if err := c.v4.Query(ctx, &q, variables); err != nil {
+			return nil, err
+		}
+		for _, f := range q.Repogitory.PullRequest.Files.Nodes {
+			paths = append(paths, string(f.Path))
+		}
+		if !q.Repogitory.PullRequest.Files.PageInfo.HasNextPage {
+			break
+		}
+		cursor = string(q.Repogitory.PullRequest.Files.PageInfo.EndCursor)
+	}
+
+	return matchCodeOwnerRules(co.d, paths), nil
+}
+
+// matchCodeOwnerRules returns the rules that own the paths in the order of the CODEOWNERS file.
+// As with GitHub, the last matching rule takes precedence for each path.
+func matchCodeOwnerRules(d *codeowners.Codeowners, paths []string) []*target.CodeOwnerRule {
+	matched := map[int]struct{}{}
+	for _, path := range paths {
+		for i := len(d.Patterns) - 1; i >= 0; i-- {
+			single := &codeowners.Codeowners{Patterns: d.Patterns[i : i+1]}
+			if len(single.Owners(path)) > 0 {
+				matched[i] = struct{}{}
+				break
+			}
+		}
+	}
+	rules := []*target.CodeOwnerRule{}
+	for i, p := range d.Patterns {
+		if _, ok := matched[i]; !ok {
+			continue
+		}
+		owners := []string{}
+		for _, o := range p.Owners {
+			owners = append(owners, strings.TrimPrefix(o, "@"))
+		}
+		rules = append(rules, &target.CodeOwnerRule{
+			Pattern: p.Pattern,
+			Owners:  owners,
+		})
+	}
+	return rules
+}
+
+// codeOwnersOf returns the owners of the rules
+func codeOwnersOf(rules []*target.CodeOwnerRule) []string {
+	co := []string{}
+	for _, r := range rules {
+		co = append(co, r.Owners...)
+	}
+	return unique(co)
+}
+
+// unapprovedCodeOwnerRules returns the patterns of the rules that none of the owners have approved.
+// A team owner ( `org/team` ) is regarded as approved when one of the members has approved.
+// Listing the members of a team requires `read:org` scope, so a failure is logged and the team is regarded as not approved.
+func (c *Client) unapprovedCodeOwnerRules(ctx context.Context, rules []*target.CodeOwnerRule, approvers []string) []string {
+	unapproved := []string{}
+	for _, r := range rules {
+		approved := false
+		for _, o := range r.Owners {
+			if strings.Contains(o, "/") && len(approvers) > 0 {
+				members, err := c.resolveTeamMembers(ctx, o)
+				if err != nil {
+					log.Warn().Msg(fmt.Sprintf("failed to get the members of %s ( `read:org` scope is required ): %s", o, err))
+					continue
+				}
+				if containsFold(members, approvers) {
+					approved = true
+					break
+				}
+				continue
+			}
+			if containsFold([]string{o}, approvers) {
+				approved = true
+				break
+			}
+		}
+		if !approved {
+			unapproved = append(unapproved, r.Pattern)
+		}
+	}
+	return unapproved
+}
+
+// resolveTeamMembers returns the members of the team ( `org/team` ). The members are cached per client.
+func (c *Client) resolveTeamMembers(ctx context.Context, team string) ([]string, error) {
+	key := strings.ToLower(team)
+	if members, ok := c.teamMembers[key]; ok {
+		return members, nil
+	}
+	members, err := c.ResolveUsers(ctx, []string{team})
+	if err != nil {
+		return nil, err
+	}
+	if c.teamMembers == nil {
+		c.teamMembers = map[string][]string{}
+	}
+	c.teamMembers[key] = members
+	return members, nil
+}
+
+// containsFold returns whether any of es is in s ( case-insensitive )
+func containsFold(s []string, es []string) bool {
+	for _, v := range s {
+		for _, e := range es {
+			if strings.EqualFold(v, e) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package gh
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v33/github"
+	"github.com/hairyhenderson/go-codeowners"
+	"github.com/k1LoW/ghdag/env"
+	"github.com/k1LoW/ghdag/target"
+)
+
+const testCodeOwners = `# comment
+*           @alice
+/docs/      @bob
+*.go        @charlie @myorg/backend
+/cmd/*.go   dave@example.com
+`
+
+func TestMatchCodeOwnerRules(t *testing.T) {
+	d, err := codeowners.FromReader(strings.NewReader(testCodeOwners), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		paths []string
+		want  []*target.CodeOwnerRule
+	}{
+		{[]string{}, []*target.CodeOwnerRule{}},
+		{[]string{"README.md"}, []*target.CodeOwnerRule{
+			{Pattern: "*", Owners: []string{"alice"}},
+		}},
+		{[]string{"docs/index.md", "gh/gh.go", "cmd/root.go"}, []*target.CodeOwnerRule{
+			{Pattern: "/docs/", Owners: []string{"bob"}},
+			{Pattern: "*.go", Owners: []string{"charlie", "myorg/backend"}},
+			{Pattern: "/cmd/*.go", Owners: []string{"dave@example.com"}},
+		}},
+	}
+	for _, tt := range tests {
+		got := matchCodeOwnerRules(d, tt.paths)
+		if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+}
+
+func TestUnapprovedCodeOwnerRules(t *testing.T) {
+	c := &Client{
+		teamMembers: map[string][]string{
+			"myorg/backend": {"eve", "frank"},
+		},
+	}
+	rules := []*target.CodeOwnerRule{
+		{Pattern: "/docs/", Owners: []string{"bob"}},
+		{Pattern: "*.go", Owners: []string{"charlie", "MyOrg/backend"}},
+	}
+	tests := []struct {
+		approvers []string
+		want      []string
+	}{
+		{[]string{}, []string{"/docs/", "*.go"}},
+		{[]string{"Bob"}, []string{"*.go"}},
+		{[]string{"frank"}, []string{"/docs/"}},
+		{[]string{"bob", "charlie"}, []string{}},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		got := c.unapprovedCodeOwnerRules(ctx, rules, tt.approvers)
+		if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+}
+
+func TestUnapprovedCodeOwnerRulesWithoutReadOrg(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+	}))
+	defer ts.Close()
+	v3 := github.NewClient(nil)
+	u, err := url.Parse(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v3.BaseURL = u
+	c := &Client{v3: v3}
+	rules := []*target.CodeOwnerRule{
+		{Pattern: "/docs/", Owners: []string{"bob"}},
+		{Pattern: "*.go", Owners: []string{"myorg/backend", "charlie"}},
+	}
+	got := c.unapprovedCodeOwnerRules(context.Background(), rules, []string{"bob", "frank"})
+	if diff := cmp.Diff(got, []string{"*.go"}, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestCodeOwnersRule(t *testing.T) {
+	d, err := codeowners.FromReader(strings.NewReader(testCodeOwners), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{
+		codeOwners: map[string]*CodeOwners{
+			"main": {Path: "CODEOWNERS", d: d},
+		},
+	}
+	// cached
+	co, err := c.GetCodeOwners(context.Background(), "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want *target.CodeOwnerRule
+	}{
+		{"README.md", &target.CodeOwnerRule{Pattern: "*", Owners: []string{"alice"}}},
+		{"docs/a/b.md", &target.CodeOwnerRule{Pattern: "/docs/", Owners: []string{"bob"}}},
+		{"cmd/root.go", &target.CodeOwnerRule{Pattern: "/cmd/*.go", Owners: []string{"dave@example.com"}}},
+	}
+	for _, tt := range tests {
+		got, ok := co.Rule(tt.path)
+		if !ok {
+			t.Errorf("not found rule: %s", tt.path)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+}
+
+func TestCodeOwnersRef(t *testing.T) {
+	p := pullRequestNode{HeadRefName: "feature", HeadRefOid: "0123abc", BaseRefName: "main"}
+	tests := []struct {
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{"", "0123abc", false},
+		{"head", "0123abc", false},
+		{"base", "main", false},
+		{"invalid", "", true},
+	}
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for _, tt := range tests {
+		os.Setenv("GHDAG_CODEOWNERS_REF", tt.env)
+		got, err := codeOwnersRef(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
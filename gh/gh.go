@@ -2,7 +2,6 @@ package gh
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -16,7 +15,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v33/github"
-	"github.com/hairyhenderson/go-codeowners"
+	"github.com/k1LoW/ghdag/env"
 	"github.com/k1LoW/ghdag/erro"
 	"github.com/k1LoW/ghdag/target"
 	"github.com/rs/zerolog/log"
@@ -35,14 +34,27 @@ type GhClient interface {
 	AddComment(ctx context.Context, n int, comment string) error
 	CloseIssue(ctx context.Context, n int) error
 	MergePullRequest(ctx context.Context, n int) error
+	UpdateBranch(ctx context.Context, n int, expectedHeadSHA, method string) error
+	BehindBy(ctx context.Context, base, head string) (int, error)
+	EnableAutoMerge(ctx context.Context, n int, method string) error
+	DisableAutoMerge(ctx context.Context, n int) error
+	SearchCount(ctx context.Context, query string) (int, error)
+	AddReaction(ctx context.Context, n int, content string) error
+	AddCommentReaction(ctx context.Context, commentID int64, content string) error
+	CreateIssue(ctx context.Context, repository, title, body string, labels, assignees []string) (int, error)
+	AddProjectItem(ctx context.Context, n int, owner string, projectNumber int, fields map[string]string) error
+	ArchiveProjectItem(ctx context.Context, owner string, projectNumber int, itemID string) error
+	UnarchiveProjectItem(ctx context.Context, owner string, projectNumber int, itemID string) error
 	ResolveUsers(ctx context.Context, in []string) ([]string, error)
 }
 
 type Client struct {
-	v3    *github.Client
-	v4    *githubv4.Client
-	owner string
-	repo  string
+	v3          *github.Client
+	v4          *githubv4.Client
+	owner       string
+	repo        string
+	teamMembers map[string][]string
+	codeOwners  map[string]*CodeOwners
 }
 
 // NewClient return Client
@@ -136,21 +148,28 @@ type issueNode struct {
 			HasNextPage bool
 		}
 	} `graphql:"comments(first: $limit, orderBy: {direction: DESC, field: UPDATED_AT})"`
+	ProjectItems projectItemsNode `graphql:"projectItems(first: 20) @include(if: $withProjectItems)"`
 }
 
 type pullRequestNode struct {
 	Author struct {
 		Login githubv4.String
 	}
-	HeadRefName    githubv4.String
-	Number         githubv4.Int
-	State          githubv4.String
-	Title          githubv4.String
-	Body           githubv4.String
-	URL            githubv4.String
-	IsDraft        githubv4.Boolean
-	ChangedFiles   githubv4.Int
-	Mergeable      githubv4.MergeableState
+	HeadRefName      githubv4.String
+	BaseRefName      githubv4.String
+	HeadRefOid       githubv4.GitObjectID
+	Number           githubv4.Int
+	State            githubv4.String
+	Title            githubv4.String
+	Body             githubv4.String
+	URL              githubv4.String
+	IsDraft          githubv4.Boolean
+	ChangedFiles     githubv4.Int
+	Mergeable        githubv4.MergeableState
+	MergeStateStatus githubv4.String
+	AutoMergeRequest *struct {
+		MergeMethod githubv4.PullRequestMergeMethod
+	}
 	ReviewDecision githubv4.PullRequestReviewDecision
 	ReviewRequests struct {
 		Nodes []struct {
@@ -200,6 +219,7 @@ type pullRequestNode struct {
 			HasNextPage bool
 		}
 	} `graphql:"comments(first: $limit, orderBy: {direction: DESC, field: UPDATED_AT})"`
+	ProjectItems projectItemsNode `graphql:"projectItems(first: 20) @include(if: $withProjectItems)"`
 }
 
 type pullRequestFilesNode struct {
@@ -237,9 +257,10 @@ func (c *Client) FetchTargets(ctx context.Context) (target.Targets, error) {
 		} `graphql:"repository(owner: $owner, name: $repo)"`
 	}
 	variables := map[string]interface{}{
-		"owner": githubv4.String(c.owner),
-		"repo":  githubv4.String(c.repo),
-		"limit": githubv4.Int(limit),
+		"owner":            githubv4.String(c.owner),
+		"repo":             githubv4.String(c.repo),
+		"limit":            githubv4.Int(limit),
+		"withProjectItems": githubv4.Boolean(env.GetenvAsBool("GHDAG_FETCH_PROJECT_ITEMS")),
 	}
 
 	if err := c.v4.Query(ctx, &q, variables); err != nil {
@@ -293,10 +314,11 @@ func (c *Client) FetchTarget(ctx context.Context, n int) (*target.Target, error)
 		} `graphql:"repository(owner: $owner, name: $repo)"`
 	}
 	variables := map[string]interface{}{
-		"owner":  githubv4.String(c.owner),
-		"repo":   githubv4.String(c.repo),
-		"number": githubv4.Int(n),
-		"limit":  githubv4.Int(limit),
+		"owner":            githubv4.String(c.owner),
+		"repo":             githubv4.String(c.repo),
+		"number":           githubv4.Int(n),
+		"limit":            githubv4.Int(limit),
+		"withProjectItems": githubv4.Boolean(env.GetenvAsBool("GHDAG_FETCH_PROJECT_ITEMS")),
 	}
 
 	if err := c.v4.Query(ctx, &q, variables); err != nil {
@@ -424,6 +446,171 @@ func (c *Client) MergePullRequest(ctx context.Context, n int) error {
 	return err
 }
 
+func (c *Client) CreateIssue(ctx context.Context, repository, title, body string, labels, assignees []string) (int, error) {
+	owner, repo, err := c.splitRepository(repository)
+	if err != nil {
+		return 0, err
+	}
+	i, _, err := c.v3.Issues.Create(ctx, owner, repo, &github.IssueRequest{
+		Title:     &title,
+		Body:      &body,
+		Labels:    &labels,
+		Assignees: &assignees,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return i.GetNumber(), nil
+}
+
+// splitRepository returns owner and repo of `owner/repo` ( default: GITHUB_REPOSITORY )
+func (c *Client) splitRepository(repository string) (string, string, error) {
+	if repository == "" {
+		return c.owner, c.repo, nil
+	}
+	splitted := strings.Split(repository, "/")
+	if len(splitted) != 2 || splitted[0] == "" || splitted[1] == "" {
+		return "", "", fmt.Errorf("invalid repository: %s", repository)
+	}
+	return splitted[0], splitted[1], nil
+}
+
+func (c *Client) AddReaction(ctx context.Context, n int, content string) error {
+	_, _, err := c.v3.Reactions.CreateIssueReaction(ctx, c.owner, c.repo, n, content)
+	return err
+}
+
+func (c *Client) AddCommentReaction(ctx context.Context, commentID int64, content string) error {
+	_, _, err := c.v3.Reactions.CreateIssueCommentReaction(ctx, c.owner, c.repo, commentID, content)
+	return err
+}
+
+// UpdatePullRequestBranchInput is an autogenerated input type of UpdatePullRequestBranch.
+type UpdatePullRequestBranchInput struct {
+	PullRequestID   githubv4.ID                    `json:"pullRequestId"`
+	ExpectedHeadOid *githubv4.GitObjectID          `json:"expectedHeadOid,omitempty"`
+	UpdateMethod    *PullRequestBranchUpdateMethod `json:"updateMethod,omitempty"`
+}
+
+// PullRequestBranchUpdateMethod is the possible methods for updating a pull request's head branch with the base branch.
+type PullRequestBranchUpdateMethod string
+
+func (c *Client) UpdateBranch(ctx context.Context, n int, expectedHeadSHA, method string) error {
+	switch method {
+	case "merge":
+		opts := &github.PullRequestBranchUpdateOptions{}
+		if expectedHeadSHA != "" {
+			opts.ExpectedHeadSHA = &expectedHeadSHA
+		}
+		if _, _, err := c.v3.PullRequests.UpdateBranch(ctx, c.owner, c.repo, n, opts); err != nil {
+			if _, ok := err.(*github.AcceptedError); ok {
+				// the update of the pull request branch is scheduled
+				return nil
+			}
+			return err
+		}
+		return nil
+	case "rebase":
+		id, err := c.getNodeID(ctx, n)
+		if err != nil {
+			return err
+		}
+		var m struct {
+			UpdatePullRequestBranch struct {
+				PullRequest struct {
+					ID githubv4.ID
+				}
+			} `graphql:"updatePullRequestBranch(input: $input)"`
+		}
+		um := PullRequestBranchUpdateMethod("REBASE")
+		input := UpdatePullRequestBranchInput{
+			PullRequestID: id,
+			UpdateMethod:  &um,
+		}
+		if expectedHeadSHA != "" {
+			oid := githubv4.GitObjectID(expectedHeadSHA)
+			input.ExpectedHeadOid = &oid
+		}
+		return c.v4.Mutate(ctx, &m, input, nil)
+	default:
+		return fmt.Errorf("invalid update method: %s", method)
+	}
+}
+
+// BehindBy returns the number of commits of the base that the head does not have ( compare API )
+func (c *Client) BehindBy(ctx context.Context, base, head string) (int, error) {
+	cc, _, err := c.v3.Repositories.CompareCommits(ctx, c.owner, c.repo, base, head)
+	if err != nil {
+		return 0, err
+	}
+	return cc.GetBehindBy(), nil
+}
+
+// EnablePullRequestAutoMergeInput is an autogenerated input type of EnablePullRequestAutoMerge.
+type EnablePullRequestAutoMergeInput struct {
+	PullRequestID githubv4.ID                      `json:"pullRequestId"`
+	MergeMethod   *githubv4.PullRequestMergeMethod `json:"mergeMethod,omitempty"`
+}
+
+// DisablePullRequestAutoMergeInput is an autogenerated input type of DisablePullRequestAutoMerge.
+type DisablePullRequestAutoMergeInput struct {
+	PullRequestID githubv4.ID `json:"pullRequestId"`
+}
+
+func (c *Client) EnableAutoMerge(ctx context.Context, n int, method string) error {
+	id, err := c.getNodeID(ctx, n)
+	if err != nil {
+		return err
+	}
+	var m struct {
+		EnablePullRequestAutoMerge struct {
+			PullRequest struct {
+				ID githubv4.ID
+			}
+		} `graphql:"enablePullRequestAutoMerge(input: $input)"`
+	}
+	mm := githubv4.PullRequestMergeMethod(strings.ToUpper(method))
+	input := EnablePullRequestAutoMergeInput{
+		PullRequestID: id,
+		MergeMethod:   &mm,
+	}
+	return c.v4.Mutate(ctx, &m, input, nil)
+}
+
+func (c *Client) DisableAutoMerge(ctx context.Context, n int) error {
+	id, err := c.getNodeID(ctx, n)
+	if err != nil {
+		return err
+	}
+	var m struct {
+		DisablePullRequestAutoMerge struct {
+			PullRequest struct {
+				ID githubv4.ID
+			}
+		} `graphql:"disablePullRequestAutoMerge(input: $input)"`
+	}
+	input := DisablePullRequestAutoMergeInput{
+		PullRequestID: id,
+	}
+	return c.v4.Mutate(ctx, &m, input, nil)
+}
+
+// SearchCount returns the number of issues and pull requests that match the search query
+func (c *Client) SearchCount(ctx context.Context, query string) (int, error) {
+	var q struct {
+		Search struct {
+			IssueCount githubv4.Int
+		} `graphql:"search(query: $query, type: ISSUE, first: 1)"`
+	}
+	variables := map[string]interface{}{
+		"query": githubv4.String(query),
+	}
+	if err := c.v4.Query(ctx, &q, variables); err != nil {
+		return 0, err
+	}
+	return int(q.Search.IssueCount), nil
+}
+
 func (c *Client) ResolveUsers(ctx context.Context, in []string) ([]string, error) {
 	res := []string{}
 	for _, inu := range in {
@@ -493,6 +680,7 @@ func buildTargetFromIssue(login string, i issueNode, now time.Time) (*target.Tar
 	for _, a := range i.Assignees.Nodes {
 		assignees = append(assignees, string(a.Login))
 	}
+	projectItems := buildProjectItems(i.ProjectItems)
 
 	return &target.Target{
 		Number:                      n,
@@ -507,10 +695,13 @@ func buildTargetFromIssue(login string, i issueNode, now time.Time) (*target.Tar
 		IsPullRequest:               false,
 		HoursElapsedSinceCreated:    int(now.Sub(i.CreatedAt.Time).Hours()),
 		HoursElapsedSinceUpdated:    int(now.Sub(i.UpdatedAt.Time).Hours()),
+		CreatedAt:                   i.CreatedAt.Time,
+		UpdatedAt:                   i.UpdatedAt.Time,
 		NumberOfComments:            len(i.Comments.Nodes),
 		LatestCommentAuthor:         string(latestComment.Author.Login),
 		LatestCommentBody:           string(latestComment.Body),
 		NumberOfConsecutiveComments: numComments,
+		ProjectItems:                projectItems,
 		Login:                       login,
 	}, nil
 }
@@ -558,6 +749,13 @@ func (c *Client) buildTargetFromPullRequest(ctx context.Context, login string, p
 	if p.Mergeable == githubv4.MergeableStateMergeable {
 		mergeable = true
 	}
+	mergeStateStatus := strings.ToLower(string(p.MergeStateStatus))
+	autoMergeEnabled := false
+	autoMergeMethod := ""
+	if p.AutoMergeRequest != nil {
+		autoMergeEnabled = true
+		autoMergeMethod = strings.ToLower(string(p.AutoMergeRequest.MergeMethod))
+	}
 
 	labels := []string{}
 	for _, l := range p.Labels.Nodes {
@@ -567,7 +765,9 @@ func (c *Client) buildTargetFromPullRequest(ctx context.Context, login string, p
 	for _, a := range p.Assignees.Nodes {
 		assignees = append(assignees, string(a.Login))
 	}
+	projectItems := buildProjectItems(p.ProjectItems)
 	reviewers := []string{}
+	requestedReviewers := []string{}
 	codeOwners := []string{}
 	codeOwnersWhoApproved := []string{}
 	for _, r := range p.ReviewRequests.Nodes {
@@ -579,6 +779,7 @@ func (c *Client) buildTargetFromPullRequest(ctx context.Context, login string, p
 			k = fmt.Sprintf("%s/%s", string(r.RequestedReviewer.Team.Organization.Login), string(r.RequestedReviewer.Team.Slug))
 		}
 		reviewers = append(reviewers, k)
+		requestedReviewers = append(requestedReviewers, k)
 		if bool(r.AsCodeOwner) {
 			codeOwners = append(codeOwners, k)
 		}
@@ -594,14 +795,29 @@ func (c *Client) buildTargetFromPullRequest(ctx context.Context, login string, p
 	}
 	reviewers = unique(reviewers)
 
+	// the CODEOWNERS rules are required to re-calc code_owners* when approved,
+	// and to compute code_owner_rules_unapproved only when the workflow refers to them
+	codeOwnerRules := []*target.CodeOwnerRule{}
+	codeOwnerRulesUnapproved := []string{}
+	fetchCodeOwnerRules := env.GetenvAsBool("GHDAG_FETCH_CODE_OWNER_RULES")
+	if fetchCodeOwnerRules || len(reviewersWhoApproved) > 0 {
+		rules, err := c.getCodeOwnerRules(ctx, p)
+		if err != nil {
+			// a failed CODEOWNERS read should not stop fetching targets
+			log.Warn().Msg(fmt.Sprintf("failed to read the CODEOWNERS rules of #%d: %s", n, err))
+		} else {
+			codeOwnerRules = rules
+		}
+	}
+	if fetchCodeOwnerRules {
+		codeOwnerRulesUnapproved = c.unapprovedCodeOwnerRules(ctx, codeOwnerRules, reviewersWhoApproved)
+	}
+
 	if len(reviewersWhoApproved) > 0 {
 		// re-calc code_owners*
 		codeOwners = []string{}
 		// calcedCodeOwners contains users that exist in the CODEOWNERS file but do not actually exist or do not have permissions.
-		calcedCodeOwners, err := c.getCodeOwners(ctx, p)
-		if err != nil {
-			return nil, err
-		}
+		calcedCodeOwners := codeOwnersOf(codeOwnerRules)
 		for _, u := range reviewersWhoApproved {
 			if contains(calcedCodeOwners, u) {
 				codeOwnersWhoApproved = append(codeOwnersWhoApproved, u)
@@ -624,109 +840,44 @@ func (c *Client) buildTargetFromPullRequest(ctx context.Context, login string, p
 		Labels:                      labels,
 		Assignees:                   assignees,
 		Reviewers:                   reviewers,
+		RequestedReviewers:          requestedReviewers,
 		CodeOwners:                  codeOwners,
 		ReviewersWhoApproved:        reviewersWhoApproved,
 		CodeOwnersWhoApproved:       codeOwnersWhoApproved,
+		CodeOwnerRules:              codeOwnerRules,
+		CodeOwnerRulesUnapproved:    codeOwnerRulesUnapproved,
 		IsIssue:                     false,
 		IsPullRequest:               true,
 		IsApproved:                  isApproved,
 		IsReviewRequired:            isReviewRequired,
 		IsChangeRequested:           isChangeRequested,
 		Mergeable:                   mergeable,
+		MergeStateStatus:            mergeStateStatus,
+		BehindBase:                  mergeStateStatus == "behind",
+		AutoMergeEnabled:            autoMergeEnabled,
+		AutoMergeMethod:             autoMergeMethod,
+		HeadSHA:                     string(p.HeadRefOid),
+		BaseRef:                     string(p.BaseRefName),
 		ChangedFiles:                int(p.ChangedFiles),
 		HoursElapsedSinceCreated:    int(now.Sub(p.CreatedAt.Time).Hours()),
 		HoursElapsedSinceUpdated:    int(now.Sub(p.UpdatedAt.Time).Hours()),
+		CreatedAt:                   p.CreatedAt.Time,
+		UpdatedAt:                   p.UpdatedAt.Time,
 		NumberOfComments:            len(p.Comments.Nodes),
 		LatestCommentAuthor:         string(latestComment.Author.Login),
 		LatestCommentBody:           string(latestComment.Body),
 		NumberOfConsecutiveComments: numComments,
+		ProjectItems:                projectItems,
 		Login:                       login,
 	}, nil
 }
 
-func (c *Client) getCodeOwners(ctx context.Context, p pullRequestNode) ([]string, error) {
-	// Get CODEOWNERS file
-	var cc string
-	for _, path := range []string{".github/CODEOWNERS", "docs/CODEOWNERS"} {
-		f, _, _, err := c.v3.Repositories.GetContents(ctx, c.owner, c.repo, path, &github.RepositoryContentGetOptions{
-			Ref: string(p.HeadRefName),
-		})
-		if err != nil {
-			continue
-		}
-
-		switch *f.Encoding {
-		case "base64":
-			if f.Content == nil {
-				break
-			}
-			c, err := base64.StdEncoding.DecodeString(*f.Content)
-			if err != nil {
-				break
-			}
-			cc = string(c)
-		case "":
-			if f.Content == nil {
-				cc = ""
-			} else {
-				cc = *f.Content
-			}
-		default:
-			break
-		}
-		break
-	}
-
-	if cc == "" {
-		return []string{}, nil
-	}
-
-	d, err := codeowners.FromReader(strings.NewReader(cc), ".")
-	if err != nil {
-		return nil, err
-	}
-
-	var cursor string
-	co := []string{}
-
-	var q struct {
-		Repogitory struct {
-			PullRequest pullRequestFilesNode `graphql:"pullRequest(number: $number)"`
-		} `graphql:"repository(owner: $owner, name: $repo)"`
-	}
-
-	for {
-		variables := map[string]interface{}{
-			"owner":  githubv4.String(c.owner),
-			"repo":   githubv4.String(c.repo),
-			"number": p.Number,
-			"limit":  githubv4.Int(limit),
-			"cursor": githubv4.String(cursor),
-		}
-		if err := c.v4.Query(ctx, &q, variables); err != nil {
-			return nil, err
-		}
-		for _, f := range q.Repogitory.PullRequest.Files.Nodes {
-			co = append(co, d.Owners(string(f.Path))...)
-		}
-		if !q.Repogitory.PullRequest.Files.PageInfo.HasNextPage {
-			break
-		}
-		cursor = string(q.Repogitory.PullRequest.Files.PageInfo.EndCursor)
-	}
-
-	codeOwners := []string{}
-	for _, o := range unique(co) {
-		codeOwners = append(codeOwners, strings.TrimPrefix(o, "@"))
-	}
-	return codeOwners, nil
-}
-
 type GitHubEvent struct {
-	Name    string
-	Number  int
-	State   string
-	Payload interface{}
+	Name      string
+	Number    int
+	State     string
+	CommentID int64
+	Payload   interface{}
 }
 
 func DecodeGitHubEvent() (*GitHubEvent, error) {
@@ -753,6 +904,9 @@ func DecodeGitHubEvent() (*GitHubEvent, error) {
 			Number int    `json:"number,omitempty"`
 			State  string `json:"state,omitempty"`
 		} `json:"issue,omitempty"`
+		Comment struct {
+			ID int64 `json:"id,omitempty"`
+		} `json:"comment,omitempty"`
 	}{}
 	if err := json.Unmarshal(b, &s); err != nil {
 		return i, err
@@ -765,6 +919,10 @@ func DecodeGitHubEvent() (*GitHubEvent, error) {
 		i.Number = s.Issue.Number
 		i.State = s.Issue.State
 	}
+	if n == "issue_comment" {
+		// the comment of other events ( ex. pull_request_review_comment, commit_comment ) is not an issue comment
+		i.CommentID = s.Comment.ID
+	}
 
 	var payload interface{}
 
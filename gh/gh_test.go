@@ -10,21 +10,24 @@ import (
 
 func TestDetectTargetNumber(t *testing.T) {
 	tests := []struct {
-		path       string
-		wantNumber int
-		wantState  string
-		wantErr    bool
+		name          string
+		path          string
+		wantNumber    int
+		wantState     string
+		wantCommentID int64
+		wantErr       bool
 	}{
-		{"event_issue_opened.json", 19, "open", false},
-		{"event_pull_request_opened.json", 20, "open", false},
-		{"event_issue_comment_opened.json", 20, "open", false},
+		{"issues", "event_issue_opened.json", 19, "open", 0, false},
+		{"pull_request", "event_pull_request_opened.json", 20, "open", 0, false},
+		{"issue_comment", "event_issue_comment_opened.json", 20, "open", 789219581, false},
+		{"pull_request_review_comment", "event_issue_comment_opened.json", 20, "open", 0, false},
 	}
 	envCache := os.Environ()
 	for _, tt := range tests {
 		if err := env.Revert(envCache); err != nil {
 			t.Fatal(err)
 		}
-		os.Setenv("GITHUB_EVENT_NAME", "test")
+		os.Setenv("GITHUB_EVENT_NAME", tt.name)
 		os.Setenv("GITHUB_EVENT_PATH", filepath.Join(testdataDir(), tt.path))
 		got, err := DecodeGitHubEvent()
 		if tt.wantErr && err != nil {
@@ -39,6 +42,9 @@ func TestDetectTargetNumber(t *testing.T) {
 		if got.State != tt.wantState {
 			t.Errorf("got %v\nwant %v", got.State, tt.wantState)
 		}
+		if got.CommentID != tt.wantCommentID {
+			t.Errorf("got %v\nwant %v", got.CommentID, tt.wantCommentID)
+		}
 	}
 }
 
@@ -0,0 +1,387 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/ghdag/target"
+	"github.com/shurcooL/githubv4"
+)
+
+type projectItemsNode struct {
+	Nodes []struct {
+		ID         githubv4.String
+		IsArchived githubv4.Boolean
+		Project    struct {
+			Number githubv4.Int
+			Title  githubv4.String
+		}
+		FieldValues struct {
+			Nodes []projectFieldValueNode
+		} `graphql:"fieldValues(first: 50)"`
+	}
+}
+
+type projectFieldValueNode struct {
+	Typename githubv4.String `graphql:"__typename"`
+	Text     struct {
+		Text  githubv4.String
+		Field projectFieldNameNode
+	} `graphql:"... on ProjectV2ItemFieldTextValue"`
+	Number struct {
+		Number githubv4.Float
+		Field  projectFieldNameNode
+	} `graphql:"... on ProjectV2ItemFieldNumberValue"`
+	Date struct {
+		Date  githubv4.String
+		Field projectFieldNameNode
+	} `graphql:"... on ProjectV2ItemFieldDateValue"`
+	SingleSelect struct {
+		Name  githubv4.String
+		Field projectFieldNameNode
+	} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	Iteration struct {
+		Title githubv4.String
+		Field projectFieldNameNode
+	} `graphql:"... on ProjectV2ItemFieldIterationValue"`
+}
+
+type projectFieldNameNode struct {
+	Common struct {
+		Name githubv4.String
+	} `graphql:"... on ProjectV2FieldCommon"`
+}
+
+type projectV2Node struct {
+	ID     githubv4.ID
+	Fields struct {
+		Nodes []struct {
+			Common struct {
+				ID       githubv4.ID
+				Name     githubv4.String
+				DataType githubv4.String
+			} `graphql:"... on ProjectV2FieldCommon"`
+			SingleSelect struct {
+				Options []struct {
+					ID   githubv4.String
+					Name githubv4.String
+				}
+			} `graphql:"... on ProjectV2SingleSelectField"`
+			Iteration struct {
+				Configuration struct {
+					Iterations []struct {
+						ID        githubv4.String
+						Title     githubv4.String
+						StartDate githubv4.String
+						Duration  githubv4.Int
+					}
+				}
+			} `graphql:"... on ProjectV2IterationField"`
+		}
+	} `graphql:"fields(first: 100)"`
+}
+
+// AddProjectV2ItemByIdInput is an autogenerated input type of AddProjectV2ItemById.
+type AddProjectV2ItemByIdInput struct {
+	ProjectID githubv4.ID `json:"projectId"`
+	ContentID githubv4.ID `json:"contentId"`
+}
+
+// UpdateProjectV2ItemFieldValueInput is an autogenerated input type of UpdateProjectV2ItemFieldValue.
+type UpdateProjectV2ItemFieldValueInput struct {
+	ProjectID githubv4.ID         `json:"projectId"`
+	ItemID    githubv4.ID         `json:"itemId"`
+	FieldID   githubv4.ID         `json:"fieldId"`
+	Value     ProjectV2FieldValue `json:"value"`
+}
+
+// ClearProjectV2ItemFieldValueInput is an autogenerated input type of ClearProjectV2ItemFieldValue.
+type ClearProjectV2ItemFieldValueInput struct {
+	ProjectID githubv4.ID `json:"projectId"`
+	ItemID    githubv4.ID `json:"itemId"`
+	FieldID   githubv4.ID `json:"fieldId"`
+}
+
+// ArchiveProjectV2ItemInput is an autogenerated input type of ArchiveProjectV2Item.
+type ArchiveProjectV2ItemInput struct {
+	ProjectID githubv4.ID `json:"projectId"`
+	ItemID    githubv4.ID `json:"itemId"`
+}
+
+// UnarchiveProjectV2ItemInput is an autogenerated input type of UnarchiveProjectV2Item.
+type UnarchiveProjectV2ItemInput struct {
+	ProjectID githubv4.ID `json:"projectId"`
+	ItemID    githubv4.ID `json:"itemId"`
+}
+
+// ProjectV2FieldValue represents the values that can be used to update a field of an item inside a Project.
+type ProjectV2FieldValue struct {
+	Text                 *githubv4.String `json:"text,omitempty"`
+	Number               *githubv4.Float  `json:"number,omitempty"`
+	Date                 *githubv4.String `json:"date,omitempty"`
+	SingleSelectOptionID *githubv4.String `json:"singleSelectOptionId,omitempty"`
+	IterationID          *githubv4.String `json:"iterationId,omitempty"`
+}
+
+func (c *Client) AddProjectItem(ctx context.Context, n int, owner string, projectNumber int, fields map[string]string) error {
+	p, itemID, err := c.addProjectItem(ctx, n, owner, projectNumber)
+	if err != nil {
+		return err
+	}
+	for name, value := range fields {
+		if err := c.setProjectItemFieldValue(ctx, p, itemID, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchiveProjectItem archives the existing item of the project
+func (c *Client) ArchiveProjectItem(ctx context.Context, owner string, projectNumber int, itemID string) error {
+	p, err := c.getProject(ctx, owner, projectNumber)
+	if err != nil {
+		return err
+	}
+	var m struct {
+		ArchiveProjectV2Item struct {
+			Item struct {
+				ID githubv4.ID
+			}
+		} `graphql:"archiveProjectV2Item(input: $input)"`
+	}
+	input := ArchiveProjectV2ItemInput{
+		ProjectID: p.ID,
+		ItemID:    githubv4.ID(itemID),
+	}
+	return c.v4.Mutate(ctx, &m, input, nil)
+}
+
+// UnarchiveProjectItem restores the archived item of the project
+func (c *Client) UnarchiveProjectItem(ctx context.Context, owner string, projectNumber int, itemID string) error {
+	p, err := c.getProject(ctx, owner, projectNumber)
+	if err != nil {
+		return err
+	}
+	var m struct {
+		UnarchiveProjectV2Item struct {
+			Item struct {
+				ID githubv4.ID
+			}
+		} `graphql:"unarchiveProjectV2Item(input: $input)"`
+	}
+	input := UnarchiveProjectV2ItemInput{
+		ProjectID: p.ID,
+		ItemID:    githubv4.ID(itemID),
+	}
+	return c.v4.Mutate(ctx, &m, input, nil)
+}
+
+// addProjectItem adds the issue or pull request to the project ( or returns the existing item )
+func (c *Client) addProjectItem(ctx context.Context, n int, owner string, projectNumber int) (*projectV2Node, githubv4.ID, error) {
+	p, err := c.getProject(ctx, owner, projectNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	contentID, err := c.getNodeID(ctx, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	var m struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID githubv4.ID
+			}
+		} `graphql:"addProjectV2ItemById(input: $input)"`
+	}
+	input := AddProjectV2ItemByIdInput{
+		ProjectID: p.ID,
+		ContentID: contentID,
+	}
+	if err := c.v4.Mutate(ctx, &m, input, nil); err != nil {
+		return nil, nil, err
+	}
+	return p, m.AddProjectV2ItemByID.Item.ID, nil
+}
+
+func (c *Client) setProjectItemFieldValue(ctx context.Context, p *projectV2Node, itemID githubv4.ID, name, value string) error {
+	for _, f := range p.Fields.Nodes {
+		if string(f.Common.Name) != name {
+			continue
+		}
+		if value == "" {
+			var m struct {
+				ClearProjectV2ItemFieldValue struct {
+					ProjectV2Item struct {
+						ID githubv4.ID
+					}
+				} `graphql:"clearProjectV2ItemFieldValue(input: $input)"`
+			}
+			input := ClearProjectV2ItemFieldValueInput{
+				ProjectID: p.ID,
+				ItemID:    itemID,
+				FieldID:   f.Common.ID,
+			}
+			return c.v4.Mutate(ctx, &m, input, nil)
+		}
+		v := ProjectV2FieldValue{}
+		switch string(f.Common.DataType) {
+		case "TEXT":
+			v.Text = githubv4.NewString(githubv4.String(value))
+		case "NUMBER":
+			fv, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid number value of project field '%s': %s", name, value)
+			}
+			v.Number = githubv4.NewFloat(githubv4.Float(fv))
+		case "DATE":
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				return fmt.Errorf("invalid date value of project field '%s': %s", name, value)
+			}
+			v.Date = githubv4.NewString(githubv4.String(value))
+		case "SINGLE_SELECT":
+			for _, o := range f.SingleSelect.Options {
+				if string(o.Name) == value {
+					v.SingleSelectOptionID = githubv4.NewString(o.ID)
+					break
+				}
+			}
+			if v.SingleSelectOptionID == nil {
+				return fmt.Errorf("not found option of project field '%s': %s", name, value)
+			}
+		case "ITERATION":
+			now := time.Now()
+			for _, i := range f.Iteration.Configuration.Iterations {
+				if value == "@current" {
+					s, err := time.Parse("2006-01-02", string(i.StartDate))
+					if err != nil {
+						return err
+					}
+					if now.Before(s) || !now.Before(s.AddDate(0, 0, int(i.Duration))) {
+						continue
+					}
+				} else if string(i.Title) != value {
+					continue
+				}
+				v.IterationID = githubv4.NewString(i.ID)
+				break
+			}
+			if v.IterationID == nil {
+				return fmt.Errorf("not found iteration of project field '%s': %s", name, value)
+			}
+		default:
+			return fmt.Errorf("unsupported project field type '%s': %s", name, f.Common.DataType)
+		}
+		var m struct {
+			UpdateProjectV2ItemFieldValue struct {
+				ProjectV2Item struct {
+					ID githubv4.ID
+				}
+			} `graphql:"updateProjectV2ItemFieldValue(input: $input)"`
+		}
+		input := UpdateProjectV2ItemFieldValueInput{
+			ProjectID: p.ID,
+			ItemID:    itemID,
+			FieldID:   f.Common.ID,
+			Value:     v,
+		}
+		return c.v4.Mutate(ctx, &m, input, nil)
+	}
+	return fmt.Errorf("not found project field: %s", name)
+}
+
+func (c *Client) getProject(ctx context.Context, owner string, projectNumber int) (*projectV2Node, error) {
+	if owner == "" {
+		owner = c.owner
+	}
+	var q struct {
+		RepositoryOwner struct {
+			Organization struct {
+				ProjectV2 projectV2Node `graphql:"projectV2(number: $number)"`
+			} `graphql:"... on Organization"`
+			User struct {
+				ProjectV2 projectV2Node `graphql:"projectV2(number: $number)"`
+			} `graphql:"... on User"`
+		} `graphql:"repositoryOwner(login: $owner)"`
+	}
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"number": githubv4.Int(projectNumber),
+	}
+	if err := c.v4.Query(ctx, &q, variables); err != nil {
+		return nil, err
+	}
+	if q.RepositoryOwner.Organization.ProjectV2.ID != nil {
+		return &q.RepositoryOwner.Organization.ProjectV2, nil
+	}
+	if q.RepositoryOwner.User.ProjectV2.ID != nil {
+		return &q.RepositoryOwner.User.ProjectV2, nil
+	}
+	return nil, fmt.Errorf("not found project: %s/%d", owner, projectNumber)
+}
+
+// getNodeID returns the node ID of the issue or pull request
+func (c *Client) getNodeID(ctx context.Context, n int) (githubv4.ID, error) {
+	var q struct {
+		Repogitory struct {
+			IssueOrPullRequest struct {
+				Issue struct {
+					ID githubv4.ID
+				} `graphql:"... on Issue"`
+				PullRequest struct {
+					ID githubv4.ID
+				} `graphql:"... on PullRequest"`
+			} `graphql:"issueOrPullRequest(number: $number)"`
+		} `graphql:"repository(owner: $owner, name: $repo)"`
+	}
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(c.owner),
+		"repo":   githubv4.String(c.repo),
+		"number": githubv4.Int(n),
+	}
+	if err := c.v4.Query(ctx, &q, variables); err != nil {
+		return nil, err
+	}
+	if q.Repogitory.IssueOrPullRequest.Issue.ID != nil {
+		return q.Repogitory.IssueOrPullRequest.Issue.ID, nil
+	}
+	if q.Repogitory.IssueOrPullRequest.PullRequest.ID != nil {
+		return q.Repogitory.IssueOrPullRequest.PullRequest.ID, nil
+	}
+	return nil, fmt.Errorf("not found issue or pull request: #%d", n)
+}
+
+func buildProjectItems(items projectItemsNode) []*target.ProjectItem {
+	pi := []*target.ProjectItem{}
+	for _, i := range items.Nodes {
+		fields := []*target.ProjectField{}
+		for _, v := range i.FieldValues.Nodes {
+			var f *target.ProjectField
+			switch string(v.Typename) {
+			case "ProjectV2ItemFieldTextValue":
+				f = &target.ProjectField{Name: string(v.Text.Field.Common.Name), Value: string(v.Text.Text)}
+			case "ProjectV2ItemFieldNumberValue":
+				f = &target.ProjectField{Name: string(v.Number.Field.Common.Name), Value: strconv.FormatFloat(float64(v.Number.Number), 'f', -1, 64)}
+			case "ProjectV2ItemFieldDateValue":
+				f = &target.ProjectField{Name: string(v.Date.Field.Common.Name), Value: strings.SplitN(string(v.Date.Date), "T", 2)[0]}
+			case "ProjectV2ItemFieldSingleSelectValue":
+				f = &target.ProjectField{Name: string(v.SingleSelect.Field.Common.Name), Value: string(v.SingleSelect.Name)}
+			case "ProjectV2ItemFieldIterationValue":
+				f = &target.ProjectField{Name: string(v.Iteration.Field.Common.Name), Value: string(v.Iteration.Title)}
+			default:
+				continue
+			}
+			fields = append(fields, f)
+		}
+		pi = append(pi, &target.ProjectItem{
+			ID:         string(i.ID),
+			Number:     int(i.Project.Number),
+			Title:      string(i.Project.Title),
+			IsArchived: bool(i.IsArchived),
+			Fields:     fields,
+		})
+	}
+	return pi
+}
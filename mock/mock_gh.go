@@ -49,6 +49,77 @@ func (mr *MockGhClientMockRecorder) AddComment(ctx, n, comment interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockGhClient)(nil).AddComment), ctx, n, comment)
 }
 
+// AddCommentReaction mocks base method.
+func (m *MockGhClient) AddCommentReaction(ctx context.Context, commentID int64, content string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCommentReaction", ctx, commentID, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCommentReaction indicates an expected call of AddCommentReaction.
+func (mr *MockGhClientMockRecorder) AddCommentReaction(ctx, commentID, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCommentReaction", reflect.TypeOf((*MockGhClient)(nil).AddCommentReaction), ctx, commentID, content)
+}
+
+// AddProjectItem mocks base method.
+func (m *MockGhClient) AddProjectItem(ctx context.Context, n int, owner string, projectNumber int, fields map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddProjectItem", ctx, n, owner, projectNumber, fields)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddProjectItem indicates an expected call of AddProjectItem.
+func (mr *MockGhClientMockRecorder) AddProjectItem(ctx, n, owner, projectNumber, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProjectItem", reflect.TypeOf((*MockGhClient)(nil).AddProjectItem), ctx, n, owner, projectNumber, fields)
+}
+
+// AddReaction mocks base method.
+func (m *MockGhClient) AddReaction(ctx context.Context, n int, content string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", ctx, n, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockGhClientMockRecorder) AddReaction(ctx, n, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockGhClient)(nil).AddReaction), ctx, n, content)
+}
+
+// ArchiveProjectItem mocks base method.
+func (m *MockGhClient) ArchiveProjectItem(ctx context.Context, owner string, projectNumber int, itemID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveProjectItem", ctx, owner, projectNumber, itemID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchiveProjectItem indicates an expected call of ArchiveProjectItem.
+func (mr *MockGhClientMockRecorder) ArchiveProjectItem(ctx, owner, projectNumber, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveProjectItem", reflect.TypeOf((*MockGhClient)(nil).ArchiveProjectItem), ctx, owner, projectNumber, itemID)
+}
+
+// BehindBy mocks base method.
+func (m *MockGhClient) BehindBy(ctx context.Context, base, head string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BehindBy", ctx, base, head)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BehindBy indicates an expected call of BehindBy.
+func (mr *MockGhClientMockRecorder) BehindBy(ctx, base, head interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BehindBy", reflect.TypeOf((*MockGhClient)(nil).BehindBy), ctx, base, head)
+}
+
 // CloseIssue mocks base method.
 func (m *MockGhClient) CloseIssue(ctx context.Context, n int) error {
 	m.ctrl.T.Helper()
@@ -63,6 +134,49 @@ func (mr *MockGhClientMockRecorder) CloseIssue(ctx, n interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseIssue", reflect.TypeOf((*MockGhClient)(nil).CloseIssue), ctx, n)
 }
 
+// CreateIssue mocks base method.
+func (m *MockGhClient) CreateIssue(ctx context.Context, repository, title, body string, labels, assignees []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIssue", ctx, repository, title, body, labels, assignees)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIssue indicates an expected call of CreateIssue.
+func (mr *MockGhClientMockRecorder) CreateIssue(ctx, repository, title, body, labels, assignees interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIssue", reflect.TypeOf((*MockGhClient)(nil).CreateIssue), ctx, repository, title, body, labels, assignees)
+}
+
+// DisableAutoMerge mocks base method.
+func (m *MockGhClient) DisableAutoMerge(ctx context.Context, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableAutoMerge", ctx, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableAutoMerge indicates an expected call of DisableAutoMerge.
+func (mr *MockGhClientMockRecorder) DisableAutoMerge(ctx, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableAutoMerge", reflect.TypeOf((*MockGhClient)(nil).DisableAutoMerge), ctx, n)
+}
+
+// EnableAutoMerge mocks base method.
+func (m *MockGhClient) EnableAutoMerge(ctx context.Context, n int, method string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableAutoMerge", ctx, n, method)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableAutoMerge indicates an expected call of EnableAutoMerge.
+func (mr *MockGhClientMockRecorder) EnableAutoMerge(ctx, n, method interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableAutoMerge", reflect.TypeOf((*MockGhClient)(nil).EnableAutoMerge), ctx, n, method)
+}
+
 // FetchTarget mocks base method.
 func (m *MockGhClient) FetchTarget(ctx context.Context, n int) (*target.Target, error) {
 	m.ctrl.T.Helper()
@@ -122,6 +236,21 @@ func (mr *MockGhClientMockRecorder) ResolveUsers(ctx, in interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveUsers", reflect.TypeOf((*MockGhClient)(nil).ResolveUsers), ctx, in)
 }
 
+// SearchCount mocks base method.
+func (m *MockGhClient) SearchCount(ctx context.Context, query string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchCount", ctx, query)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCount indicates an expected call of SearchCount.
+func (mr *MockGhClientMockRecorder) SearchCount(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockGhClient)(nil).SearchCount), ctx, query)
+}
+
 // SetAssignees mocks base method.
 func (m *MockGhClient) SetAssignees(ctx context.Context, n int, assignees []string) error {
 	m.ctrl.T.Helper()
@@ -163,3 +292,31 @@ func (mr *MockGhClientMockRecorder) SetReviewers(ctx, n, reviewers interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReviewers", reflect.TypeOf((*MockGhClient)(nil).SetReviewers), ctx, n, reviewers)
 }
+
+// UnarchiveProjectItem mocks base method.
+func (m *MockGhClient) UnarchiveProjectItem(ctx context.Context, owner string, projectNumber int, itemID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnarchiveProjectItem", ctx, owner, projectNumber, itemID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnarchiveProjectItem indicates an expected call of UnarchiveProjectItem.
+func (mr *MockGhClientMockRecorder) UnarchiveProjectItem(ctx, owner, projectNumber, itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnarchiveProjectItem", reflect.TypeOf((*MockGhClient)(nil).UnarchiveProjectItem), ctx, owner, projectNumber, itemID)
+}
+
+// UpdateBranch mocks base method.
+func (m *MockGhClient) UpdateBranch(ctx context.Context, n int, expectedHeadSHA, method string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBranch", ctx, n, expectedHeadSHA, method)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBranch indicates an expected call of UpdateBranch.
+func (mr *MockGhClientMockRecorder) UpdateBranch(ctx, n, expectedHeadSHA, method interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBranch", reflect.TypeOf((*MockGhClient)(nil).UpdateBranch), ctx, n, expectedHeadSHA, method)
+}
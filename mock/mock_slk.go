@@ -9,6 +9,8 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	slk "github.com/k1LoW/ghdag/slk"
+	slack "github.com/slack-go/slack"
 )
 
 // MockSlkClient is a mock of SlkClient interface.
@@ -34,6 +36,34 @@ func (m *MockSlkClient) EXPECT() *MockSlkClientMockRecorder {
 	return m.recorder
 }
 
+// DeleteMessages mocks base method.
+func (m *MockSlkClient) DeleteMessages(ctx context.Context, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessages", ctx, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMessages indicates an expected call of DeleteMessages.
+func (mr *MockSlkClientMockRecorder) DeleteMessages(ctx, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessages", reflect.TypeOf((*MockSlkClient)(nil).DeleteMessages), ctx, n)
+}
+
+// ForgetMessages mocks base method.
+func (m *MockSlkClient) ForgetMessages(n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgetMessages", n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgetMessages indicates an expected call of ForgetMessages.
+func (mr *MockSlkClientMockRecorder) ForgetMessages(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetMessages", reflect.TypeOf((*MockSlkClient)(nil).ForgetMessages), n)
+}
+
 // GetMentionLinkByName mocks base method.
 func (m *MockSlkClient) GetMentionLinkByName(ctx context.Context, name string) (string, error) {
 	m.ctrl.T.Helper()
@@ -49,16 +79,41 @@ func (mr *MockSlkClientMockRecorder) GetMentionLinkByName(ctx, name interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMentionLinkByName", reflect.TypeOf((*MockSlkClient)(nil).GetMentionLinkByName), ctx, name)
 }
 
-// PostMessage mocks base method.
-func (m_2 *MockSlkClient) PostMessage(ctx context.Context, m string) error {
+// PostDirectMessage mocks base method.
+func (m_2 *MockSlkClient) PostDirectMessage(ctx context.Context, name, m string, blocks ...slack.Block) error {
 	m_2.ctrl.T.Helper()
-	ret := m_2.ctrl.Call(m_2, "PostMessage", ctx, m)
+	varargs := []interface{}{ctx, name, m}
+	for _, a := range blocks {
+		varargs = append(varargs, a)
+	}
+	ret := m_2.ctrl.Call(m_2, "PostDirectMessage", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// PostDirectMessage indicates an expected call of PostDirectMessage.
+func (mr *MockSlkClientMockRecorder) PostDirectMessage(ctx, name, m interface{}, blocks ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, name, m}, blocks...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostDirectMessage", reflect.TypeOf((*MockSlkClient)(nil).PostDirectMessage), varargs...)
+}
+
+// PostMessage mocks base method.
+func (m_2 *MockSlkClient) PostMessage(ctx context.Context, m string, blocks ...slack.Block) (*slk.Message, error) {
+	m_2.ctrl.T.Helper()
+	varargs := []interface{}{ctx, m}
+	for _, a := range blocks {
+		varargs = append(varargs, a)
+	}
+	ret := m_2.ctrl.Call(m_2, "PostMessage", varargs...)
+	ret0, _ := ret[0].(*slk.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // PostMessage indicates an expected call of PostMessage.
-func (mr *MockSlkClientMockRecorder) PostMessage(ctx, m interface{}) *gomock.Call {
+func (mr *MockSlkClientMockRecorder) PostMessage(ctx, m interface{}, blocks ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostMessage", reflect.TypeOf((*MockSlkClient)(nil).PostMessage), ctx, m)
+	varargs := append([]interface{}{ctx, m}, blocks...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostMessage", reflect.TypeOf((*MockSlkClient)(nil).PostMessage), varargs...)
 }
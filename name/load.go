@@ -0,0 +1,78 @@
+package name
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Load loads linked names from the YAML or CSV file
+func Load(path string) (LinkedNames, error) {
+	b, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		l := LinkedNames{}
+		if err := yaml.Unmarshal(b, &l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	case ".csv":
+		return loadCSV(string(b))
+	default:
+		return nil, fmt.Errorf("unsupported file type of linked names: %s", path)
+	}
+}
+
+// loadCSV loads linked names from CSV with the header row.
+// Tags are separated by spaces.
+func loadCSV(in string) (LinkedNames, error) {
+	r := csv.NewReader(strings.NewReader(in))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	l := LinkedNames{}
+	if len(records) == 0 {
+		return l, nil
+	}
+	header := records[0]
+	for _, rec := range records[1:] {
+		n := &LinkedName{}
+		for i, h := range header {
+			if i >= len(rec) {
+				break
+			}
+			v := strings.TrimSpace(rec[i])
+			switch strings.TrimSpace(h) {
+			case "github":
+				n.Github = v
+			case "slack":
+				n.Slack = v
+			case "slack_id":
+				n.SlackID = v
+			case "email":
+				n.Email = v
+			case "display_name":
+				n.DisplayName = v
+			case "tags":
+				n.Tags = strings.Fields(v)
+			case "timezone":
+				n.Timezone = v
+			case "away_until":
+				n.AwayUntil = v
+			default:
+				return nil, fmt.Errorf("invalid column of linked names: %s", h)
+			}
+		}
+		l = append(l, n)
+	}
+	return l, nil
+}
@@ -1,12 +1,27 @@
 package name
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
 
 type LinkedName struct {
 	Github string
 	Slack  string
+	// SlackID is a precomputed Slack user ID ( U... ) or user group ID ( S... ).
+	// Mentions are rendered by the ID without calling Slack API.
+	SlackID     string   `yaml:"slack_id,omitempty"`
+	Email       string   `yaml:"email,omitempty"`
+	DisplayName string   `yaml:"display_name,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	Timezone    string   `yaml:"timezone,omitempty"`
+	// AwayUntil is the last date ( YYYY-MM-DD ) of absence in Timezone.
+	AwayUntil string `yaml:"away_until,omitempty"`
 }
 
+var slackIDRe = regexp.MustCompile(`^[UWS][A-Z0-9]{8,}$`)
+
 type LinkedNames []*LinkedName
 
 func (l LinkedNames) CheckSyntax() (bool, []string) {
@@ -26,6 +41,10 @@ func (l LinkedNames) CheckSyntax() (bool, []string) {
 			g[n.Github] = i
 		}
 
+		if n.Slack == "" {
+			// only slack_id is given
+			continue
+		}
 		if j, ok := g[n.Slack]; ok {
 			valid = false
 			errors = append(errors, fmt.Sprintf("'%s' is found in both linkedNames[%d].slack and linkedNames[%d].slack", n.Slack, i, j))
@@ -34,6 +53,23 @@ func (l LinkedNames) CheckSyntax() (bool, []string) {
 		}
 	}
 
+	for i, n := range l {
+		if n.SlackID != "" && !slackIDRe.MatchString(n.SlackID) {
+			valid = false
+			errors = append(errors, fmt.Sprintf("'%s' of linkedNames[%d].slack_id is not a Slack user ID or user group ID", n.SlackID, i))
+		}
+		if _, err := n.location(); err != nil {
+			valid = false
+			errors = append(errors, fmt.Sprintf("invalid linkedNames[%d].timezone: %s", i, err))
+		}
+		if n.AwayUntil != "" {
+			if _, err := time.Parse(dateLayout, n.AwayUntil); err != nil {
+				valid = false
+				errors = append(errors, fmt.Sprintf("invalid linkedNames[%d].away_until: %s", i, err))
+			}
+		}
+	}
+
 	for n, i := range g {
 		if j, ok := s[n]; ok {
 			valid = false
@@ -48,9 +84,13 @@ func (l LinkedNames) ToGithubNames(in []string) []string {
 	if len(l) == 0 {
 		return in
 	}
+	in = l.expandTags(in, func(n *LinkedName) string { return n.Github })
 	m := map[string]string{}
 	for _, n := range l {
 		m[n.Slack] = n.Github
+		if n.SlackID != "" {
+			m[n.SlackID] = n.Github
+		}
 	}
 	o := []string{}
 	for _, n := range in {
@@ -67,8 +107,20 @@ func (l LinkedNames) ToSlackNames(in []string) []string {
 	if len(l) == 0 {
 		return in
 	}
+	in = l.expandTags(in, func(n *LinkedName) string { return n.Github })
 	m := map[string]string{}
 	for _, n := range l {
+		if n.SlackID != "" {
+			m[n.Github] = n.SlackID
+			if n.Slack != "" {
+				m[n.Slack] = n.SlackID
+			}
+			continue
+		}
+		if n.Slack == "" && n.Email != "" {
+			m[n.Github] = n.Email
+			continue
+		}
 		m[n.Github] = n.Slack
 	}
 	o := []string{}
@@ -81,3 +133,81 @@ func (l LinkedNames) ToSlackNames(in []string) []string {
 	}
 	return o
 }
+
+const (
+	tagPrefix  = "tag:"
+	dateLayout = "2006-01-02"
+)
+
+// expandTags expands team tags ( `tag:<tag>` ) into the names of the members
+func (l LinkedNames) expandTags(in []string, f func(n *LinkedName) string) []string {
+	o := []string{}
+	for _, s := range in {
+		if len(s) <= len(tagPrefix) || s[:len(tagPrefix)] != tagPrefix {
+			o = append(o, s)
+			continue
+		}
+		tag := s[len(tagPrefix):]
+		for _, n := range l {
+			if contains(n.Tags, tag) && f(n) != "" {
+				o = append(o, f(n))
+			}
+		}
+	}
+	return o
+}
+
+// ExcludeAway returns the names excluding those who are away at the time
+func (l LinkedNames) ExcludeAway(in []string, now time.Time) (available []string, away []string) {
+	available = []string{}
+	away = []string{}
+	for _, s := range in {
+		if n := l.find(s); n != nil && n.IsAway(now) {
+			away = append(away, s)
+			continue
+		}
+		available = append(available, s)
+	}
+	return available, away
+}
+
+// IsAway returns whether the person is away at the time
+func (n *LinkedName) IsAway(now time.Time) bool {
+	if n.AwayUntil == "" {
+		return false
+	}
+	loc, err := n.location()
+	if err != nil {
+		return false
+	}
+	until, err := time.ParseInLocation(dateLayout, n.AwayUntil, loc)
+	if err != nil {
+		return false
+	}
+	return now.Before(until.AddDate(0, 0, 1))
+}
+
+func (n *LinkedName) location() (*time.Location, error) {
+	if n.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(n.Timezone)
+}
+
+func (l LinkedNames) find(s string) *LinkedName {
+	for _, n := range l {
+		if s != "" && (n.Github == s || n.Slack == s || n.SlackID == s || n.Email == s) {
+			return n
+		}
+	}
+	return nil
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
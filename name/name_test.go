@@ -1,7 +1,9 @@
 package name
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -59,6 +61,28 @@ func TestCheckSyntax(t *testing.T) {
 			},
 			false,
 		},
+		{
+			LinkedNames{
+				&LinkedName{
+					Github:  "bob",
+					SlackID: "U012AB3CDE",
+				},
+				&LinkedName{
+					Github:  "org/team",
+					SlackID: "S0614TZR7A",
+				},
+			},
+			true,
+		},
+		{
+			LinkedNames{
+				&LinkedName{
+					Github:  "bob",
+					SlackID: "bob_marly",
+				},
+			},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		got, _ := tt.names.CheckSyntax()
@@ -114,6 +138,22 @@ func TestLinkedNames(t *testing.T) {
 			[]string{"alice_liddel", "bob", "charlie_sheen"},
 			[]string{"alice_liddel", "bob_marly", "charlie_sheen"},
 		},
+		{
+			LinkedNames{
+				&LinkedName{
+					Github:  "bob",
+					Slack:   "bob_marly",
+					SlackID: "U012AB3CDE",
+				},
+				&LinkedName{
+					Github:  "org/team",
+					SlackID: "S0614TZR7A",
+				},
+			},
+			[]string{"alice", "bob", "bob_marly", "org/team"},
+			[]string{"alice", "bob", "bob", "org/team"},
+			[]string{"alice", "U012AB3CDE", "U012AB3CDE", "S0614TZR7A"},
+		},
 	}
 	for _, tt := range tests {
 		gotGithub := tt.names.ToGithubNames(tt.in)
@@ -126,3 +166,68 @@ func TestLinkedNames(t *testing.T) {
 		}
 	}
 }
+
+func TestLoad(t *testing.T) {
+	for _, f := range []string{"linked_names.csv", "linked_names.yml"} {
+		l, err := Load(filepath.Join("..", "testdata", f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, errs := l.CheckSyntax(); !ok {
+			t.Errorf("%s: %v", f, errs)
+		}
+		want := LinkedNames{
+			&LinkedName{
+				Github:      "bob",
+				Slack:       "bob_marly",
+				Email:       "bob@example.com",
+				DisplayName: "Bob Marley",
+				Tags:        []string{"backend", "reviewers"},
+				Timezone:    "Asia/Tokyo",
+				AwayUntil:   "2021-01-10",
+			},
+			&LinkedName{
+				Github:      "alice",
+				Email:       "alice@example.com",
+				DisplayName: "Alice Liddell",
+				Tags:        []string{"frontend", "reviewers"},
+			},
+		}
+		if diff := cmp.Diff(l, want, nil); diff != "" {
+			t.Errorf("%s: %s", f, diff)
+		}
+	}
+}
+
+func TestTagsAndAway(t *testing.T) {
+	l, err := Load(filepath.Join("..", "testdata", "linked_names.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(l.ToGithubNames([]string{"tag:reviewers", "charlie"}), []string{"bob", "alice", "charlie"}, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+	if diff := cmp.Diff(l.ToSlackNames([]string{"tag:reviewers"}), []string{"bob_marly", "alice@example.com"}, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	tests := []struct {
+		now           time.Time
+		wantAvailable []string
+		wantAway      []string
+	}{
+		// 2021-01-10 23:00 in Asia/Tokyo
+		{time.Date(2021, 1, 10, 14, 0, 0, 0, time.UTC), []string{"alice", "charlie"}, []string{"bob"}},
+		// 2021-01-11 00:00 in Asia/Tokyo
+		{time.Date(2021, 1, 10, 15, 0, 0, 0, time.UTC), []string{"bob", "alice", "charlie"}, []string{}},
+	}
+	for _, tt := range tests {
+		available, away := l.ExcludeAway([]string{"bob", "alice", "charlie"}, tt.now)
+		if diff := cmp.Diff(available, tt.wantAvailable, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+		if diff := cmp.Diff(away, tt.wantAway, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+}
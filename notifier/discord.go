@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Discord is a notifier using Discord webhooks
+type Discord struct {
+	client *http.Client
+}
+
+func (n *Discord) Notify(ctx context.Context, nt *Notification) (string, error) {
+	return notifyText(ctx, "discord", nt, n.send)
+}
+
+func (n *Discord) send(ctx context.Context, m string) error {
+	url := os.Getenv("DISCORD_WEBHOOK_URL")
+	if url == "" {
+		return errors.New("not found environment for Discord: DISCORD_WEBHOOK_URL")
+	}
+	// https://discord.com/developers/docs/resources/webhook#execute-webhook
+	body := map[string]string{
+		"content": fmt.Sprintf("%s\n%s", m, footer()),
+	}
+	if username := os.Getenv("DISCORD_USERNAME"); username != "" {
+		body["username"] = username
+	}
+	return postJSON(ctx, n.client, url, body)
+}
@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+
+	"github.com/k1LoW/ghdag/env"
+)
+
+const defaultSMTPPort = "25"
+
+// Email is a notifier using SMTP
+type Email struct{}
+
+func (n *Email) Notify(ctx context.Context, nt *Notification) (string, error) {
+	return notifyText(ctx, "email", nt, n.send)
+}
+
+func (n *Email) send(ctx context.Context, m string) error {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return errors.New("not found environment for email: SMTP_HOST")
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = defaultSMTPPort
+	}
+	from := os.Getenv("EMAIL_FROM")
+	if from == "" {
+		return errors.New("not found environment for email: EMAIL_FROM")
+	}
+	to, err := env.Split(os.Getenv("EMAIL_TO"))
+	if err != nil {
+		return err
+	}
+	if len(to) == 0 {
+		return errors.New("not found environment for email: EMAIL_TO")
+	}
+	subject := os.Getenv("EMAIL_SUBJECT")
+	if subject == "" {
+		subject = fmt.Sprintf("[%s] #%s %s", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GHDAG_TARGET_NUMBER"), os.Getenv("GHDAG_TASK_ID"))
+	}
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return smtp.SendMail(net.JoinHostPort(host, port), auth, from, to, buildEmail(from, to, subject, m))
+}
+
+func buildEmail(from string, to []string, subject, m string) []byte {
+	h := []string{
+		fmt.Sprintf("From: %s", from),
+		fmt.Sprintf("To: %s", strings.Join(to, ", ")),
+		fmt.Sprintf("Subject: %s", mime.QEncoding.Encode("UTF-8", subject)),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.ReplaceAll(fmt.Sprintf("%s\n\n%s\n", m, footer()), "\n", "\r\n")
+	return []byte(fmt.Sprintf("%s\r\n\r\n%s", strings.Join(h, "\r\n"), body))
+}
@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Notification is a message of the `notify:` action
+type Notification struct {
+	Text string
+	// Blocks is a Block Kit layout. Notifiers other than Slack send only the text.
+	Blocks []interface{}
+	Footer bool
+}
+
+// Notifier is a backend of the `notify:` action
+type Notifier interface {
+	// Notify sends the notification and returns the sent message
+	Notify(ctx context.Context, n *Notification) (string, error)
+}
+
+// New returns the notifier of the kind.
+// The Slack notifier depends on the state of the session ( mentions, linked names ), so it is created by the runner.
+func New(kind string) (Notifier, error) {
+	switch kind {
+	case "teams":
+		return &Teams{client: http.DefaultClient}, nil
+	case "discord":
+		return &Discord{client: http.DefaultClient}, nil
+	case "webhook":
+		return &Webhook{client: http.DefaultClient}, nil
+	case "email":
+		return &Email{}, nil
+	default:
+		return nil, fmt.Errorf("invalid notifier: %s", kind)
+	}
+}
+
+// notifyText sends the text of the notification
+func notifyText(ctx context.Context, kind string, n *Notification, send func(context.Context, string) error) (string, error) {
+	if n.Text == "" {
+		return "", fmt.Errorf("`notify.text:` is required for the notifier: %s", kind)
+	}
+	if err := send(ctx, n.Text); err != nil {
+		return "", err
+	}
+	return n.Text, nil
+}
+
+// footer returns the footer text same as that of Slack messages
+func footer() string {
+	if os.Getenv("GHDAG_TARGET_NUMBER") == "" {
+		return fmt.Sprintf("%s | %s", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GHDAG_TASK_ID"))
+	}
+	return fmt.Sprintf("%s | #%s %s | %s", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GHDAG_TARGET_NUMBER"), os.Getenv("GHDAG_TARGET_URL"), os.Getenv("GHDAG_TASK_ID"))
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return post(ctx, client, url, b)
+}
+
+func post(ctx context.Context, client *http.Client, url string, b []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("failed to post notification: %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/ghdag/env"
+)
+
+func TestWebhookNotifiers(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	tests := []struct {
+		kind    string
+		env     map[string]string
+		wantKey string
+		want    string
+	}{
+		{"teams", map[string]string{}, "text", "hello"},
+		{"discord", map[string]string{"DISCORD_USERNAME": "ghdag"}, "content", "hello"},
+		{"webhook", map[string]string{}, "text", "hello"},
+		{"webhook", map[string]string{"GHDAG_WEBHOOK_BODY": `{"msg": {{ .Message | json }}, "task": {{ .Env.GHDAG_TASK_ID | json }}}`}, "task", "task-id"},
+	}
+	for _, tt := range tests {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]interface{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Error(err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		for _, k := range []string{"TEAMS_WEBHOOK_URL", "DISCORD_WEBHOOK_URL", "GHDAG_WEBHOOK_URL"} {
+			if err := os.Setenv(k, ts.URL); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Setenv("GHDAG_TASK_ID", "task-id"); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range tt.env {
+			if err := os.Setenv(k, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		n, err := New(tt.kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := n.Notify(context.Background(), &Notification{Text: tt.want}); err != nil {
+			t.Fatal(err)
+		}
+		ts.Close()
+		v, ok := got[tt.wantKey].(string)
+		if !ok || !strings.HasPrefix(v, tt.want) {
+			t.Errorf("%s: got %v\nwant %v", tt.kind, got, tt.want)
+		}
+		if u, ok := tt.env["DISCORD_USERNAME"]; ok && got["username"] != u {
+			t.Errorf("got %v\nwant %v", got["username"], u)
+		}
+	}
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+	if err := os.Setenv("GHDAG_WEBHOOK_URL", ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	n, err := New("webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.Notify(context.Background(), &Notification{Text: "hello"}); err == nil {
+		t.Error("want error")
+	}
+	if err := os.Setenv("GHDAG_WEBHOOK_BODY", `{"text": {{ .Message }}}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.Notify(context.Background(), &Notification{Text: "hello"}); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	got := make(chan string, 1)
+	go serveSMTP(t, l, got)
+
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("SMTP_HOST", host); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("SMTP_PORT", port); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("EMAIL_FROM", "ghdag@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("EMAIL_TO", "alice@example.com bob@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("EMAIL_SUBJECT", "Reminder"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := New("email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.Notify(context.Background(), &Notification{Text: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	data := <-got
+	for _, want := range []string{"To: alice@example.com, bob@example.com", "Subject: Reminder", "hello"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("got %v\nwant %v", data, want)
+		}
+	}
+}
+
+func TestBuildEmail(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"Reminder", "Subject: Reminder\r\n"},
+		{"レビュー依頼", "Subject: =?UTF-8?q?"},
+	}
+	for _, tt := range tests {
+		got := string(buildEmail("ghdag@example.com", []string{"alice@example.com"}, tt.subject, "hello"))
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+// serveSMTP is a minimal SMTP stand-in that accepts one message
+func serveSMTP(t *testing.T, l net.Listener, got chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(s string) {
+		_, _ = w.WriteString(s + "\r\n")
+		_ = w.Flush()
+	}
+	reply("220 localhost ESMTP")
+	data := []string{}
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if inData {
+			if line == "." {
+				inData = false
+				got <- strings.Join(data, "\n")
+				reply("250 OK")
+				continue
+			}
+			data = append(data, line)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+			reply("250 localhost")
+		case line == "DATA":
+			inData = true
+			reply("354 End data with <CR><LF>.<CR><LF>")
+		case line == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
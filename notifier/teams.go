@@ -0,0 +1,32 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Teams is a notifier using Microsoft Teams incoming webhooks
+type Teams struct {
+	client *http.Client
+}
+
+func (n *Teams) Notify(ctx context.Context, nt *Notification) (string, error) {
+	return notifyText(ctx, "teams", nt, n.send)
+}
+
+func (n *Teams) send(ctx context.Context, m string) error {
+	url := os.Getenv("TEAMS_WEBHOOK_URL")
+	if url == "" {
+		return errors.New("not found environment for Microsoft Teams: TEAMS_WEBHOOK_URL")
+	}
+	// https://docs.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+	body := map[string]string{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"text":     fmt.Sprintf("%s\n\n%s", m, footer()),
+	}
+	return postJSON(ctx, n.client, url, body)
+}
@@ -0,0 +1,67 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"text/template"
+
+	"github.com/k1LoW/ghdag/env"
+)
+
+const defaultWebhookBody = `{"text": {{ .Message | json }}}`
+
+// Webhook is a notifier using generic JSON webhooks
+type Webhook struct {
+	client *http.Client
+}
+
+func (n *Webhook) Notify(ctx context.Context, nt *Notification) (string, error) {
+	return notifyText(ctx, "webhook", nt, n.send)
+}
+
+func (n *Webhook) send(ctx context.Context, m string) error {
+	url := os.Getenv("GHDAG_WEBHOOK_URL")
+	if url == "" {
+		return errors.New("not found environment for webhook: GHDAG_WEBHOOK_URL")
+	}
+	b, err := renderWebhookBody(os.Getenv("GHDAG_WEBHOOK_BODY"), m)
+	if err != nil {
+		return err
+	}
+	return post(ctx, n.client, url, b)
+}
+
+// renderWebhookBody renders the body template with the message and environment variables
+func renderWebhookBody(tmpl, m string) ([]byte, error) {
+	if tmpl == "" {
+		tmpl = defaultWebhookBody
+	}
+	funcs := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+	}
+	t, err := template.New("body").Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, map[string]interface{}{
+		"Message": m,
+		"Env":     env.EnvMap(),
+	}); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("invalid JSON body of webhook")
+	}
+	return buf.Bytes(), nil
+}
@@ -3,6 +3,7 @@ package runner
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -11,20 +12,28 @@ import (
 	"strings"
 	"time"
 
+	"github.com/antonmedv/expr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/k1LoW/duration"
 	"github.com/k1LoW/exec"
 	"github.com/k1LoW/ghdag/env"
 	"github.com/k1LoW/ghdag/erro"
+	"github.com/k1LoW/ghdag/notifier"
+	"github.com/k1LoW/ghdag/slk"
 	"github.com/k1LoW/ghdag/target"
 	"github.com/k1LoW/ghdag/task"
 	"github.com/lestrrat-go/backoff/v2"
+	"github.com/slack-go/slack"
 )
 
 func (r *Runner) PerformRunAction(ctx context.Context, _ *target.Target, command string) error {
 	r.log(fmt.Sprintf("Run command: %s", command))
 	max := 0
 	timeout := 300 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		// `timeout:` of the task
+		timeout = time.Until(deadline)
+	}
 	p := backoff.Null()
 	if os.Getenv("GHDAG_ACTION_RUN_RETRY_MAX") != "" || os.Getenv("GHDAG_ACTION_RUN_RETRY_TIMEOUT") != "" {
 		mini := 0 * time.Second
@@ -78,22 +87,8 @@ func (r *Runner) PerformRunAction(ctx context.Context, _ *target.Target, command
 	count := 0
 	var err error
 	for backoff.Continue(c) {
-		c := exec.CommandContext(ctx2, "sh", "-c", command)
-		c.Env = os.Environ()
-		outbuf := new(bytes.Buffer)
-		outmw := io.MultiWriter(os.Stdout, outbuf)
-		c.Stdout = outmw
-		errbuf := new(bytes.Buffer)
-		errmw := io.MultiWriter(os.Stderr, errbuf)
-		c.Stderr = errmw
-		err = c.Run()
+		err = r.runCommand(ctx2, command)
 		count += 1
-		if err := os.Setenv("GHDAG_ACTION_RUN_STDOUT", outbuf.String()); err != nil {
-			return err
-		}
-		if err := os.Setenv("GHDAG_ACTION_RUN_STDERR", errbuf.String()); err != nil {
-			return err
-		}
 		if err != nil {
 			if count > max {
 				if max > 0 {
@@ -108,6 +103,38 @@ func (r *Runner) PerformRunAction(ctx context.Context, _ *target.Target, command
 	return err
 }
 
+// runCommand runs the command once ( an attempt of the `run:` action ).
+// Invalid outputs are also regarded as a failure of the attempt, so they are retried.
+func (r *Runner) runCommand(ctx context.Context, command string) error {
+	of, err := os.CreateTemp("", "ghdag_output")
+	if err != nil {
+		return err
+	}
+	_ = of.Close()
+	defer func() {
+		_ = os.Remove(of.Name())
+	}()
+	c := exec.CommandContext(ctx, "sh", "-c", command)
+	c.Env = append(os.Environ(), fmt.Sprintf("GHDAG_OUTPUT=%s", of.Name()))
+	outbuf := new(bytes.Buffer)
+	outmw := io.MultiWriter(os.Stdout, outbuf)
+	c.Stdout = outmw
+	errbuf := new(bytes.Buffer)
+	errmw := io.MultiWriter(os.Stderr, errbuf)
+	c.Stderr = errmw
+	err = c.Run()
+	if err := os.Setenv("GHDAG_ACTION_RUN_STDOUT", outbuf.String()); err != nil {
+		return err
+	}
+	if err := os.Setenv("GHDAG_ACTION_RUN_STDERR", errbuf.String()); err != nil {
+		return err
+	}
+	if oerr := r.setRunOutputs(of.Name(), outbuf.Bytes(), err == nil); oerr != nil && err == nil {
+		return oerr
+	}
+	return err
+}
+
 func (r *Runner) PerformLabelsAction(ctx context.Context, i *target.Target, labels []string) error {
 	b := os.Getenv("GHDAG_ACTION_LABELS_BEHAVIOR")
 	switch b {
@@ -148,15 +175,25 @@ func (r *Runner) PerformLabelsAction(ctx context.Context, i *target.Target, labe
 }
 
 func (r *Runner) PerformAssigneesAction(ctx context.Context, i *target.Target, assignees []string) error {
-	assignees = r.config.LinkedNames.ToGithubNames(assignees)
+	return r.PerformAssigneesCandidatesAction(ctx, i, &task.Candidates{Users: assignees})
+}
+
+func (r *Runner) PerformAssigneesCandidatesAction(ctx context.Context, i *target.Target, c *task.Candidates) error {
+	assignees := r.config.LinkedNames.ToGithubNames(c.Users)
 	assignees, err := r.github.ResolveUsers(ctx, assignees)
 	if err != nil {
 		return err
 	}
-	assignees, err = r.sample(assignees, "GITHUB_ASSIGNEES_SAMPLE")
+	assignees = r.excludeAway(assignees)
+	assignees, err = r.excludeCandidates(ctx, i, assignees, c.Exclude)
 	if err != nil {
 		return err
 	}
+	assignees, err = r.sampleByStrategy(ctx, c.Users, assignees, i.Author, "GITHUB_ASSIGNEES")
+	if err != nil {
+		return err
+	}
+	selected := assignees
 	b := os.Getenv("GHDAG_ACTION_ASSIGNEES_BEHAVIOR")
 	switch b {
 	case "add":
@@ -189,6 +226,9 @@ func (r *Runner) PerformAssigneesAction(ctx context.Context, i *target.Target, a
 	if err := r.github.SetAssignees(ctx, i.Number, assignees); err != nil {
 		return err
 	}
+	if b != "remove" {
+		r.addWorkload("assignees", selected)
+	}
 	if err := os.Setenv("GHDAG_ACTION_ASSIGNEES_UPDATED", env.Join(assignees)); err != nil {
 		return err
 	}
@@ -196,14 +236,22 @@ func (r *Runner) PerformAssigneesAction(ctx context.Context, i *target.Target, a
 }
 
 func (r *Runner) PerformReviewersAction(ctx context.Context, i *target.Target, reviewers []string) error {
-	reviewers = r.config.LinkedNames.ToGithubNames(reviewers)
-	if contains(reviewers, i.Author) {
-		r.debuglog(fmt.Sprintf("Exclude author from reviewers: %s", reviewers))
-		if err := r.setExcludeKey(reviewers, i.Author); err != nil {
-			return err
-		}
+	return r.PerformReviewersCandidatesAction(ctx, i, &task.Candidates{Users: reviewers})
+}
+
+func (r *Runner) PerformReviewersCandidatesAction(ctx context.Context, i *target.Target, c *task.Candidates) error {
+	var (
+		reviewers []string
+		err       error
+	)
+	switch c.From {
+	case task.CandidatesFromCodeOwners:
+		reviewers, err = r.selectCodeOwnerReviewers(ctx, i, c.Exclude)
+	case "":
+		reviewers, err = r.selectReviewers(ctx, i, c)
+	default:
+		err = fmt.Errorf("invalid from: %s", c.From)
 	}
-	reviewers, err := r.sample(reviewers, "GITHUB_REVIEWERS_SAMPLE")
 	if err != nil {
 		return err
 	}
@@ -218,11 +266,12 @@ func (r *Runner) PerformReviewersAction(ctx context.Context, i *target.Target, r
 
 	ra := []string{}
 	for _, r := range reviewers {
-		if contains(i.CodeOwners, r) {
+		if i.IsCodeOwner(r) {
 			continue
 		}
-		ra = append(ra, r)
+		ra = append(ra, target.NormalizeReviewer(r))
 	}
+	ra = unique(ra)
 	sortStringSlice(ra)
 
 	if len(ra) == 0 || cmp.Equal(rb, ra) {
@@ -234,6 +283,7 @@ func (r *Runner) PerformReviewersAction(ctx context.Context, i *target.Target, r
 	if err := r.github.SetReviewers(ctx, i.Number, ra); err != nil {
 		return err
 	}
+	r.addWorkload("reviewers", ra)
 	if err := os.Setenv("GHDAG_ACTION_REVIEWERS_UPDATED", env.Join(ra)); err != nil {
 		return err
 	}
@@ -246,7 +296,7 @@ func (r *Runner) PerformCommentAction(ctx context.Context, i *target.Target, com
 	if err != nil {
 		return err
 	}
-	mentions, err = r.sample(mentions, "GITHUB_COMMENT_MENTIONS_SAMPLE")
+	mentions, err = r.sampleByStrategy(ctx, mentions, mentions, i.Author, "GITHUB_COMMENT_MENTIONS")
 	if err != nil {
 		return err
 	}
@@ -303,39 +353,452 @@ func (r *Runner) PerformStateAction(ctx context.Context, i *target.Target, state
 	if err := os.Setenv("GHDAG_ACTION_STATE_CHANGED", state); err != nil {
 		return err
 	}
+	r.deleteSlackMessages(ctx, i.Number)
 	return nil
 }
 
-func (r *Runner) PerformNotifyAction(ctx context.Context, _ *target.Target, notify string) error {
-	n := os.ExpandEnv(notify)
-	mentions, err := env.Split(os.Getenv("SLACK_MENTIONS"))
+func (r *Runner) PerformUpdateBranchAction(ctx context.Context, i *target.Target, method string) error {
+	switch method {
+	case "merge", "rebase":
+	default:
+		return fmt.Errorf("invalid update method: %s", method)
+	}
+	if !i.IsPullRequest {
+		return fmt.Errorf("#%d is not a pull request", i.Number)
+	}
+	if !i.BehindBase {
+		// mergeStateStatus is BEHIND only when the branch protection requires branches to be up to date
+		behind, err := r.github.BehindBy(ctx, i.BaseRef, i.HeadSHA)
+		if err != nil {
+			return err
+		}
+		if behind == 0 {
+			if err := os.Setenv("GHDAG_ACTION_BRANCH_UPDATED", method); err != nil {
+				return err
+			}
+			return erro.NewAlreadyInStateError(fmt.Errorf("the branch is not behind the base branch: %s", i.BaseRef))
+		}
+	}
+	r.log(fmt.Sprintf("Update branch: %s", method))
+	if err := r.github.UpdateBranch(ctx, i.Number, i.HeadSHA, method); err != nil {
+		return err
+	}
+	if err := os.Setenv("GHDAG_ACTION_BRANCH_UPDATED", method); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Runner) PerformAutoMergeAction(ctx context.Context, i *target.Target, method string) error {
+	if !i.IsPullRequest {
+		return fmt.Errorf("#%d is not a pull request", i.Number)
+	}
+	switch method {
+	case "merge", "squash", "rebase":
+		if i.AutoMergeEnabled && i.AutoMergeMethod == method {
+			if err := os.Setenv("GHDAG_ACTION_AUTO_MERGE_UPDATED", method); err != nil {
+				return err
+			}
+			return erro.NewAlreadyInStateError(fmt.Errorf("auto-merge is already enabled: %s", method))
+		}
+		r.log(fmt.Sprintf("Enable auto-merge: %s", method))
+		if err := r.github.EnableAutoMerge(ctx, i.Number, method); err != nil {
+			return err
+		}
+	case "disable", "disabled":
+		method = "disabled"
+		if !i.AutoMergeEnabled {
+			if err := os.Setenv("GHDAG_ACTION_AUTO_MERGE_UPDATED", method); err != nil {
+				return err
+			}
+			return erro.NewAlreadyInStateError(errors.New("auto-merge is already disabled"))
+		}
+		r.log("Disable auto-merge")
+		if err := r.github.DisableAutoMerge(ctx, i.Number); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid merge method: %s", method)
+	}
+	if err := os.Setenv("GHDAG_ACTION_AUTO_MERGE_UPDATED", method); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Runner) PerformNotifyAction(ctx context.Context, i *target.Target, notify string) error {
+	return r.PerformNotifyLayoutAction(ctx, i, &task.Notify{Text: notify})
+}
+
+// PerformNotifyLayoutAction sends the notification with the Block Kit layout
+func (r *Runner) PerformNotifyLayoutAction(ctx context.Context, i *target.Target, notify *task.Notify) error {
+	if notify.Digest != "" {
+		return r.addDigest(ctx, notify)
+	}
+	kinds, err := env.Split(os.Getenv("GHDAG_NOTIFIER"))
 	if err != nil {
 		return err
 	}
+	if len(kinds) == 0 {
+		kinds = []string{"slack"}
+	}
+	n := &notifier.Notification{
+		Text:   os.ExpandEnv(notify.Text),
+		Footer: notify.WithFooter(),
+	}
+	if len(notify.Blocks) > 0 {
+		n.Blocks = expandEnvAll(notify.Blocks).([]interface{})
+	}
+	sent := ""
+	for _, kind := range kinds {
+		nt, err := r.notifier(kind, i)
+		if err != nil {
+			return err
+		}
+		r.log(fmt.Sprintf("Send notification via %s: %s", kind, n.Text))
+		sent, err = nt.Notify(ctx, n)
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.Setenv("GHDAG_ACTION_NOTIFY_SENT", sent); err != nil {
+		return err
+	}
+	return nil
+}
+
+// slackNotifier is the notifier using Slack. It resolves mentions with the linked names and the strategy of the session.
+type slackNotifier struct {
+	r      *Runner
+	author string
+}
+
+func (s *slackNotifier) Notify(ctx context.Context, nt *notifier.Notification) (string, error) {
+	return s.r.performNotifySlack(ctx, nt, s.author)
+}
+
+func (r *Runner) performNotifySlack(ctx context.Context, nt *notifier.Notification, author string) (string, error) {
+	n := nt.Text
+	var blocks []slack.Block
+	if len(nt.Blocks) > 0 {
+		b, err := json.Marshal(nt.Blocks)
+		if err != nil {
+			return "", err
+		}
+		blocks, err = slk.BuildBlocks(b, nt.Footer)
+		if err != nil {
+			return "", err
+		}
+	}
+	if os.Getenv("SLACK_DIRECT_MESSAGE_TO") != "" {
+		return n, r.performNotifyDirectMessage(ctx, n, blocks)
+	}
+	mentions, err := env.Split(os.Getenv("SLACK_MENTIONS"))
+	if err != nil {
+		return "", err
+	}
 	mentions = r.config.LinkedNames.ToSlackNames(mentions)
-	mentions, err = r.sample(mentions, "SLACK_MENTIONS_SAMPLE")
+	if a := r.config.LinkedNames.ToSlackNames([]string{author}); author != "" && len(a) == 1 {
+		author = a[0]
+	}
+	mentions, err = r.sampleByStrategy(ctx, mentions, mentions, author, "SLACK_MENTIONS")
 	if err != nil {
-		return err
+		return "", err
 	}
-	r.log(fmt.Sprintf("Send notification: %s", n))
-	if os.Getenv("SLACK_WEBHOOK_URL") != "" && len(mentions) > 0 {
-		return errors.New("notification using webhook does not support mentions")
+	if os.Getenv("SLACK_WEBHOOK_URL") != "" {
+		for _, m := range mentions {
+			if !slk.IsMentionID(m) {
+				return "", fmt.Errorf("notification using webhook supports only mentions with Slack IDs ( `linkedNames[*].slack_id` ): %s", m)
+			}
+		}
 	}
 	links := []string{}
 	for _, m := range mentions {
 		l, err := r.slack.GetMentionLinkByName(ctx, m)
 		if err != nil {
-			return err
+			return "", err
 		}
 		links = append(links, l)
 	}
 	if len(links) > 0 {
-		n = fmt.Sprintf("%s %s", strings.Join(links, " "), n)
+		n = strings.TrimSuffix(fmt.Sprintf("%s %s", strings.Join(links, " "), n), " ")
+		if len(blocks) > 0 {
+			mb := slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", strings.Join(links, " "), false, false), nil, nil)
+			blocks = append([]slack.Block{mb}, blocks...)
+		}
+	}
+	if _, err := r.slack.PostMessage(ctx, n, blocks...); err != nil {
+		return "", err
 	}
-	if err := r.slack.PostMessage(ctx, n); err != nil {
+	return n, nil
+}
+
+func (r *Runner) performNotifyDirectMessage(ctx context.Context, n string, blocks []slack.Block) error {
+	to, err := env.Split(os.Getenv("SLACK_DIRECT_MESSAGE_TO"))
+	if err != nil {
+		return err
+	}
+	to = unique(r.config.LinkedNames.ToSlackNames(to))
+	for _, u := range to {
+		r.log(fmt.Sprintf("Send direct message to %s: %s", u, n))
+		if err := r.slack.PostDirectMessage(ctx, u, n, blocks...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// excludeAway excludes people who are away ( `linkedNames[*].away_until:` ) from candidates
+func (r *Runner) excludeAway(in []string) []string {
+	available, away := r.config.LinkedNames.ExcludeAway(in, time.Now())
+	if len(away) > 0 {
+		r.debuglog(fmt.Sprintf("Exclude away from candidates: %s", away))
+	}
+	return available
+}
+
+func (r *Runner) selectReviewers(ctx context.Context, i *target.Target, c *task.Candidates) ([]string, error) {
+	reviewers := r.config.LinkedNames.ToGithubNames(c.Users)
+	if env.GetenvAsBool("GHDAG_ACTION_REVIEWERS_EXPAND_TEAMS") {
+		// expand teams into members to select reviewers from them
+		expanded, err := r.github.ResolveUsers(ctx, reviewers)
+		if err != nil {
+			return nil, err
+		}
+		reviewers = expanded
+	}
+	reviewers = r.excludeAway(reviewers)
+	reviewers, err := r.excludeCandidates(ctx, i, reviewers, c.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	if contains(reviewers, i.Author) {
+		r.debuglog(fmt.Sprintf("Exclude author from reviewers: %s", reviewers))
+		if err := r.setExcludeKey(reviewers, i.Author); err != nil {
+			return nil, err
+		}
+	}
+	return r.sampleByStrategy(ctx, c.Users, reviewers, i.Author, "GITHUB_REVIEWERS")
+}
+
+// excludeCandidates excludes users from the candidates by the rules of `exclude:`
+func (r *Runner) excludeCandidates(ctx context.Context, i *target.Target, in []string, ex *task.Exclude) ([]string, error) {
+	if ex == nil {
+		return in, nil
+	}
+	excluded := []string{}
+	if ex.Approved {
+		excluded = append(excluded, i.ReviewersWhoApproved...)
+	}
+	if ex.Assigned {
+		excluded = append(excluded, i.Assignees...)
+	}
+	if len(ex.Teams) > 0 {
+		members, err := r.github.ResolveUsers(ctx, ex.Teams)
+		if err != nil {
+			return nil, err
+		}
+		excluded = append(excluded, members...)
+	}
+	var variables map[string]interface{}
+	if ex.If != "" {
+		variables = r.variables(i)
+	}
+	res := []string{}
+	for _, u := range in {
+		if contains(excluded, strings.TrimPrefix(u, "@")) {
+			continue
+		}
+		if ex.Bots && isBot(u) {
+			continue
+		}
+		if ex.If != "" && !strings.Contains(u, "/") {
+			variables["user"] = strings.TrimPrefix(u, "@")
+			ok, err := expr.Eval(fmt.Sprintf("(%s) == true", ex.If), variables)
+			if err != nil {
+				return nil, err
+			}
+			if ok.(bool) {
+				continue
+			}
+		}
+		res = append(res, u)
+	}
+	if len(res) < len(in) {
+		r.debuglog(fmt.Sprintf("Exclude from candidates: %s", diff(in, res)))
+	}
+	return res, nil
+}
+
+func isBot(u string) bool {
+	u = strings.TrimPrefix(u, "@")
+	return strings.HasSuffix(u, "[bot]") || strings.HasSuffix(u, "-bot")
+}
+
+func diff(a, b []string) []string {
+	res := []string{}
+	for _, v := range a {
+		if !contains(b, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// notifier returns the notifier of the kind. The Slack notifier skips the author of the target ( if any ) in mentions.
+func (r *Runner) notifier(kind string, i *target.Target) (notifier.Notifier, error) {
+	if kind == "slack" {
+		s := &slackNotifier{r: r}
+		if i != nil {
+			s.author = i.Author
+		}
+		return s, nil
+	}
+	if nt, ok := r.notifiers[kind]; ok {
+		return nt, nil
+	}
+	nt, err := notifier.New(kind)
+	if err != nil {
+		return nil, err
+	}
+	r.notifiers[kind] = nt
+	return nt, nil
+}
+
+// expandEnvAll expands environment variables in all string values of the Block Kit layout
+func expandEnvAll(in interface{}) interface{} {
+	switch v := in.(type) {
+	case string:
+		return os.ExpandEnv(v)
+	case []interface{}:
+		o := make([]interface{}, len(v))
+		for i, e := range v {
+			o[i] = expandEnvAll(e)
+		}
+		return o
+	case map[string]interface{}:
+		o := map[string]interface{}{}
+		for k, e := range v {
+			o[k] = expandEnvAll(e)
+		}
+		return o
+	default:
+		return v
+	}
+}
+
+func (r *Runner) PerformReactionAction(ctx context.Context, i *target.Target, reaction string) error {
+	content := strings.Trim(reaction, ":")
+	if !contains(task.Reactions, content) {
+		return fmt.Errorf("invalid reaction: %s", reaction)
+	}
+	b := os.Getenv("GHDAG_ACTION_REACTION_TARGET")
+	switch b {
+	case "comment", "":
+		if r.event.CommentID > 0 && r.event.Number == i.Number {
+			r.log(fmt.Sprintf("Add reaction to the comment that triggered the event: %s", content))
+			if err := r.github.AddCommentReaction(ctx, r.event.CommentID, content); err != nil {
+				return err
+			}
+			break
+		}
+		if b == "comment" {
+			return errors.New("not found the comment that triggered the event")
+		}
+		r.log(fmt.Sprintf("Add reaction: %s", content))
+		if err := r.github.AddReaction(ctx, i.Number, content); err != nil {
+			return err
+		}
+	case "target":
+		r.log(fmt.Sprintf("Add reaction: %s", content))
+		if err := r.github.AddReaction(ctx, i.Number, content); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid reaction target: %s", b)
+	}
+	if err := os.Setenv("GHDAG_ACTION_REACTION_ADDED", content); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Runner) PerformProjectAction(ctx context.Context, i *target.Target, p *task.Project) error {
+	fields := map[string]string{}
+	kv := []string{}
+	for k, v := range p.Fields {
+		fields[k] = os.ExpandEnv(v)
+		kv = append(kv, fmt.Sprintf("%s=%s", k, fields[k]))
+	}
+	sortStringSlice(kv)
+
+	pi, ok := i.ProjectItem(p.Number)
+	if p.Archive && !ok {
+		return fmt.Errorf("not found the item of project #%d to archive ( GHDAG_FETCH_PROJECT_ITEMS must be enabled )", p.Number)
+	}
+	if ok && pi.IsArchived == p.Archive {
+		updated := false
+		for k, v := range fields {
+			if fv, _ := pi.Field(k); fv != v {
+				updated = true
+				break
+			}
+		}
+		if !updated {
+			if err := os.Setenv("GHDAG_ACTION_PROJECT_UPDATED", strconv.Itoa(p.Number)); err != nil {
+				return err
+			}
+			return erro.NewAlreadyInStateError(fmt.Errorf("the target is already in a state of being wanted: project #%d %s", p.Number, strings.Join(kv, ", ")))
+		}
+	}
+
+	r.log(fmt.Sprintf("Add to project #%d: %s", p.Number, strings.Join(kv, ", ")))
+	if err := r.github.AddProjectItem(ctx, i.Number, p.Owner, p.Number, fields); err != nil {
+		return err
+	}
+	switch {
+	case p.Archive:
+		r.log(fmt.Sprintf("Archive the item of project #%d", p.Number))
+		if err := r.github.ArchiveProjectItem(ctx, p.Owner, p.Number, pi.ID); err != nil {
+			return err
+		}
+	case ok && pi.IsArchived:
+		// adding the item does not restore the archived item
+		r.log(fmt.Sprintf("Unarchive the item of project #%d", p.Number))
+		if err := r.github.UnarchiveProjectItem(ctx, p.Owner, p.Number, pi.ID); err != nil {
+			return err
+		}
+	}
+	if err := os.Setenv("GHDAG_ACTION_PROJECT_UPDATED", strconv.Itoa(p.Number)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Runner) PerformCreateIssueAction(ctx context.Context, _ *target.Target, ci *task.CreateIssue) error {
+	title := os.ExpandEnv(ci.Title)
+	body := os.ExpandEnv(ci.Body)
+	repository := os.ExpandEnv(ci.Repository)
+	labels := []string{}
+	for _, l := range ci.Labels {
+		labels = append(labels, os.ExpandEnv(l))
+	}
+	assignees := []string{}
+	for _, a := range ci.Assignees {
+		assignees = append(assignees, os.ExpandEnv(a))
+	}
+	assignees = r.config.LinkedNames.ToGithubNames(assignees)
+	assignees, err := r.github.ResolveUsers(ctx, assignees)
+	if err != nil {
+		return err
+	}
+
+	r.log(fmt.Sprintf("Create issue: %s", title))
+	n, err := r.github.CreateIssue(ctx, repository, title, body, labels, assignees)
+	if err != nil {
 		return err
 	}
-	if err := os.Setenv("GHDAG_ACTION_NOTIFY_SENT", n); err != nil {
+	if err := os.Setenv("GHDAG_ACTION_ISSUE_CREATED", strconv.Itoa(n)); err != nil {
 		return err
 	}
 	return nil
@@ -344,12 +807,18 @@ func (r *Runner) PerformNotifyAction(ctx context.Context, _ *target.Target, noti
 var propagatableEnv = []string{
 	"GHDAG_ACTION_RUN_STDOUT",
 	"GHDAG_ACTION_RUN_STDERR",
+	outputsEnvKey,
 	"GHDAG_ACTION_LABELS_UPDATED",
 	"GHDAG_ACTION_ASSIGNEES_UPDATED",
 	"GHDAG_ACTION_REVIEWERS_UPDATED",
 	"GHDAG_ACTION_COMMENT_CREATED",
 	"GHDAG_ACTION_STATE_CHANGED",
+	"GHDAG_ACTION_BRANCH_UPDATED",
+	"GHDAG_ACTION_AUTO_MERGE_UPDATED",
 	"GHDAG_ACTION_NOTIFY_SENT",
+	"GHDAG_ACTION_REACTION_ADDED",
+	"GHDAG_ACTION_PROJECT_UPDATED",
+	"GHDAG_ACTION_ISSUE_CREATED",
 	"GHDAG_ACTION_DO_ERROR",
 }
 
@@ -9,13 +9,20 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bxcodec/faker/v3"
 	"github.com/golang/mock/gomock"
 	"github.com/k1LoW/ghdag/env"
 	"github.com/k1LoW/ghdag/erro"
+	"github.com/k1LoW/ghdag/gh"
 	"github.com/k1LoW/ghdag/mock"
+	"github.com/k1LoW/ghdag/name"
+	"github.com/k1LoW/ghdag/notifier"
+	"github.com/k1LoW/ghdag/slk"
 	"github.com/k1LoW/ghdag/target"
+	"github.com/k1LoW/ghdag/task"
+	"github.com/slack-go/slack"
 )
 
 func TestPerformRunAction(t *testing.T) {
@@ -243,6 +250,131 @@ func TestPerformReviewersAction(t *testing.T) {
 	}
 }
 
+func TestPerformReviewersActionWithTeams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	tests := []struct {
+		in                []string
+		expand            bool
+		members           []string
+		current           []string
+		currentCodeOwners []string
+		want              []string
+		wantErr           interface{}
+	}{
+		{[]string{"@myorg/backend"}, false, nil, []string{}, []string{}, []string{"myorg/backend"}, nil},
+		{[]string{"@myorg/backend"}, false, nil, []string{"MyOrg/Backend"}, []string{}, []string{"myorg/backend"}, &erro.AlreadyInStateError{}},
+		{[]string{"alice"}, false, nil, []string{"myorg/backend", "alice"}, []string{"MyOrg/Backend"}, []string{"alice"}, &erro.AlreadyInStateError{}},
+		{[]string{"myorg/backend", "alice"}, false, nil, []string{"myorg/backend"}, []string{"myorg/backend"}, []string{"alice"}, nil},
+		{[]string{"myorg/backend"}, true, []string{"bob", "charlie"}, []string{}, []string{}, []string{"bob", "charlie"}, nil},
+		{[]string{"myorg/backend"}, true, []string{"bob", "eve"}, []string{"myorg/backend"}, []string{}, []string{"bob"}, nil},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		r.excludeKey = -1
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		i.Author = "eve"
+		i.Reviewers = tt.current
+		i.CodeOwners = tt.currentCodeOwners
+		if tt.expand {
+			if err := os.Setenv("GHDAG_ACTION_REVIEWERS_EXPAND_TEAMS", "true"); err != nil {
+				t.Fatal(err)
+			}
+			m.EXPECT().ResolveUsers(gomock.Eq(ctx), gomock.Eq(tt.in)).Return(tt.members, nil)
+		}
+		if tt.wantErr == nil {
+			m.EXPECT().SetReviewers(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq(tt.want)).Return(nil)
+		}
+		if err := r.PerformReviewersAction(ctx, i, tt.in); err != nil {
+			if !errors.As(err, tt.wantErr) {
+				t.Errorf("got %v\nwant %v", err, tt.wantErr)
+			}
+		}
+		if got := os.Getenv("GHDAG_ACTION_REVIEWERS_UPDATED"); got != env.Join(tt.want) {
+			t.Errorf("got %v\nwant %v", got, env.Join(tt.want))
+		}
+	}
+}
+
+func TestPerformReviewersCandidatesActionExclude(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	tests := []struct {
+		exclude *task.Exclude
+		teams   []string
+		want    []string
+	}{
+		{nil, nil, []string{"alice", "bob", "charlie", "dave", "renovate[bot]"}},
+		{&task.Exclude{Approved: true}, nil, []string{"bob", "charlie", "dave", "renovate[bot]"}},
+		{&task.Exclude{Assigned: true}, nil, []string{"alice", "charlie", "dave", "renovate[bot]"}},
+		{&task.Exclude{Bots: true}, nil, []string{"alice", "bob", "charlie", "dave"}},
+		{&task.Exclude{Teams: []string{"myorg/leads"}}, []string{"charlie", "dave"}, []string{"alice", "bob", "renovate[bot]"}},
+		{&task.Exclude{If: `user startsWith "d" || user == author`}, nil, []string{"alice", "bob", "charlie", "renovate[bot]"}},
+		{&task.Exclude{Approved: true, Assigned: true, Bots: true, If: `user == "dave"`}, nil, []string{"charlie"}},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		r.excludeKey = -1
+		i.Author = "eve"
+		i.Reviewers = []string{}
+		i.CodeOwners = []string{}
+		i.ReviewersWhoApproved = []string{"alice"}
+		i.Assignees = []string{"bob"}
+		if tt.teams != nil {
+			m.EXPECT().ResolveUsers(gomock.Eq(ctx), gomock.Eq(tt.exclude.Teams)).Return(tt.teams, nil)
+		}
+		m.EXPECT().SetReviewers(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq(tt.want)).Return(nil)
+		c := &task.Candidates{
+			Users:   []string{"alice", "bob", "charlie", "dave", "eve", "renovate[bot]"},
+			Exclude: tt.exclude,
+		}
+		if err := r.PerformReviewersCandidatesAction(ctx, i, c); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 func TestPerformCommentAction(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -315,6 +447,8 @@ func TestPerformStateAction(t *testing.T) {
 
 	m := mock.NewMockGhClient(ctrl)
 	r.github = m
+	s := mock.NewMockSlkClient(ctrl)
+	r.slack = s
 
 	tests := []struct {
 		in      string
@@ -337,11 +471,13 @@ func TestPerformStateAction(t *testing.T) {
 		switch tt.in {
 		case "close", "closed":
 			m.EXPECT().CloseIssue(gomock.Eq(ctx), gomock.Eq(i.Number)).Return(nil)
+			s.EXPECT().ForgetMessages(gomock.Eq(i.Number)).Return(nil)
 			if err := r.PerformStateAction(ctx, i, tt.in); err != nil {
 				t.Error(err)
 			}
 		case "merge", "merged":
 			m.EXPECT().MergePullRequest(gomock.Eq(ctx), gomock.Eq(i.Number)).Return(nil)
+			s.EXPECT().ForgetMessages(gomock.Eq(i.Number)).Return(nil)
 			if err := r.PerformStateAction(ctx, i, tt.in); err != nil {
 				t.Error(err)
 			}
@@ -356,6 +492,160 @@ func TestPerformStateAction(t *testing.T) {
 	}
 }
 
+func TestPerformStateActionDeleteMessagesFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	s := mock.NewMockSlkClient(ctrl)
+	r.slack = s
+	if err := os.Setenv("SLACK_DELETE_MESSAGES_ON_CLOSE", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	i := &target.Target{Number: 3}
+	m.EXPECT().CloseIssue(gomock.Eq(ctx), gomock.Eq(3)).Return(nil)
+	s.EXPECT().DeleteMessages(gomock.Eq(ctx), gomock.Eq(3)).Return(errors.New("channel_not_found"))
+	s.EXPECT().ForgetMessages(gomock.Eq(3)).Return(nil)
+	// the target has already been closed
+	if err := r.PerformStateAction(ctx, i, "close"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPerformUpdateBranchAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	tests := []struct {
+		in               string
+		mergeStateStatus string
+		behindBy         int
+		want             string
+		wantUpdate       bool
+		wantErr          bool
+	}{
+		{"merge", "behind", 0, "merge", true, false},
+		{"rebase", "behind", 0, "rebase", true, false},
+		{"merge", "unknown", 1, "merge", true, false},
+		{"merge", "clean", 2, "merge", true, false},
+		{"merge", "blocked", 1, "merge", true, false},
+		{"merge", "clean", 0, "merge", false, true},
+		{"squash", "behind", 0, "", false, true},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		i.IsPullRequest = true
+		i.MergeStateStatus = tt.mergeStateStatus
+		i.BehindBase = (tt.mergeStateStatus == "behind")
+		if !i.BehindBase && tt.in != "squash" {
+			m.EXPECT().BehindBy(gomock.Eq(ctx), gomock.Eq(i.BaseRef), gomock.Eq(i.HeadSHA)).Return(tt.behindBy, nil)
+		}
+		if tt.wantUpdate {
+			m.EXPECT().UpdateBranch(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq(i.HeadSHA), gomock.Eq(tt.in)).Return(nil)
+		}
+		if err := r.PerformUpdateBranchAction(ctx, i, tt.in); (err != nil) != tt.wantErr {
+			t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+		}
+		if got := os.Getenv("GHDAG_ACTION_BRANCH_UPDATED"); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestPerformAutoMergeAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	tests := []struct {
+		in            string
+		enabled       bool
+		currentMethod string
+		want          string
+		wantErr       interface{}
+	}{
+		{"squash", false, "", "squash", nil},
+		{"squash", true, "merge", "squash", nil},
+		{"squash", true, "squash", "squash", &erro.AlreadyInStateError{}},
+		{"disable", true, "merge", "disabled", nil},
+		{"disable", false, "", "disabled", &erro.AlreadyInStateError{}},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		i.IsPullRequest = true
+		i.AutoMergeEnabled = tt.enabled
+		i.AutoMergeMethod = tt.currentMethod
+		if tt.wantErr == nil {
+			switch tt.in {
+			case "disable":
+				m.EXPECT().DisableAutoMerge(gomock.Eq(ctx), gomock.Eq(i.Number)).Return(nil)
+			default:
+				m.EXPECT().EnableAutoMerge(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq(tt.in)).Return(nil)
+			}
+		}
+		if err := r.PerformAutoMergeAction(ctx, i, tt.in); err != nil {
+			if !errors.As(err, tt.wantErr) {
+				t.Errorf("got %v\nwant %v", err, tt.wantErr)
+			}
+		}
+		if got := os.Getenv("GHDAG_ACTION_AUTO_MERGE_UPDATED"); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
 func TestPerformNotifyAction(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -400,7 +690,7 @@ func TestPerformNotifyAction(t *testing.T) {
 			t.Fatal(err)
 		}
 		if tt.wantErr == nil {
-			m.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Eq(tt.want)).Return(nil)
+			m.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Eq(tt.want)).Return(nil, nil)
 			for _, mention := range tt.wantMentions {
 				m.EXPECT().GetMentionLinkByName(gomock.Eq(ctx), gomock.Eq(mention)).Return(fmt.Sprintf("<U%s>", strings.ToUpper(mention)), nil)
 			}
@@ -414,6 +704,556 @@ func TestPerformNotifyAction(t *testing.T) {
 	}
 }
 
+func TestPerformNotifyActionWithWebhookMentions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockSlkClient(ctrl)
+	r.slack = m
+	r.config.LinkedNames = name.LinkedNames{
+		&name.LinkedName{Github: "alice", SlackID: "U012AB3CDE"},
+	}
+
+	tests := []struct {
+		mentionsEnv string
+		want        string
+		wantErr     bool
+	}{
+		{"alice", "<@U012AB3CDE> hello", false},
+		{"here", "<!here> hello", false},
+		{"bob", "", true},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		if err := os.Setenv("SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/dummy"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("SLACK_MENTIONS", tt.mentionsEnv); err != nil {
+			t.Fatal(err)
+		}
+		if !tt.wantErr {
+			m.EXPECT().GetMentionLinkByName(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, n string) (string, error) {
+				if n == "here" {
+					return "<!here>", nil
+				}
+				return fmt.Sprintf("<@%s>", n), nil
+			})
+			m.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Eq(tt.want)).Return(nil, nil)
+		}
+		err := r.PerformNotifyAction(ctx, nil, "hello")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got %v\nwant error %v", err, tt.wantErr)
+		}
+	}
+}
+
+func TestPerformNotifyLayoutAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockSlkClient(ctrl)
+	r.slack = m
+
+	footer := false
+	tests := []struct {
+		in          *task.Notify
+		mentionsEnv string
+		wantBlocks  int
+	}{
+		{
+			&task.Notify{
+				Text: "hello",
+				Blocks: []interface{}{
+					map[string]interface{}{"type": "section", "text": map[string]interface{}{"type": "mrkdwn", "text": "${GHDAG_TEST_TITLE}"}},
+					map[string]interface{}{"type": "divider"},
+				},
+			},
+			"",
+			3,
+		},
+		{
+			&task.Notify{
+				Text: "hello",
+				Blocks: []interface{}{
+					map[string]interface{}{"type": "divider"},
+				},
+				Footer: &footer,
+			},
+			"",
+			1,
+		},
+		{
+			&task.Notify{
+				Text: "hello",
+				Blocks: []interface{}{
+					map[string]interface{}{"type": "divider"},
+				},
+			},
+			"alice",
+			3,
+		},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("SLACK_API_TOKEN", "dummy"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("SLACK_MENTIONS", tt.mentionsEnv); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("GHDAG_TEST_TITLE", "*title*"); err != nil {
+			t.Fatal(err)
+		}
+		if tt.mentionsEnv != "" {
+			m.EXPECT().GetMentionLinkByName(gomock.Eq(ctx), gomock.Eq(tt.mentionsEnv)).Return("<@UALICE>", nil)
+		}
+		wantBlocks := tt.wantBlocks
+		m.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, blocks ...slack.Block) (*slk.Message, error) {
+			if len(blocks) != wantBlocks {
+				t.Errorf("got %v\nwant %v", len(blocks), wantBlocks)
+			}
+			if s, ok := blocks[0].(*slack.SectionBlock); ok && s.Text.Text == "${GHDAG_TEST_TITLE}" {
+				t.Errorf("env is not expanded: %v", s.Text.Text)
+			}
+			return nil, nil
+		})
+		if err := r.PerformNotifyLayoutAction(ctx, i, tt.in); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPerformAssigneesActionExcludeAway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	r.config.LinkedNames = name.LinkedNames{
+		&name.LinkedName{Github: "alice", AwayUntil: time.Now().AddDate(0, 0, 1).Format("2006-01-02")},
+		&name.LinkedName{Github: "bob", AwayUntil: time.Now().AddDate(0, 0, -2).Format("2006-01-02")},
+	}
+
+	ctx := context.Background()
+	i := &target.Target{}
+	if err := faker.FakeData(i); err != nil {
+		t.Fatal(err)
+	}
+	i.Assignees = []string{}
+	m.EXPECT().ResolveUsers(gomock.Eq(ctx), gomock.Eq([]string{"alice", "bob", "charlie"})).Return([]string{"alice", "bob", "charlie"}, nil)
+	m.EXPECT().SetAssignees(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq([]string{"bob", "charlie"})).Return(nil)
+	if err := r.PerformAssigneesAction(ctx, i, []string{"alice", "bob", "charlie"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPerformAssigneesActionRoundRobinSkipAuthor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for k, v := range map[string]string{
+		"GHDAG_STATE_FILE":          filepath.Join(t.TempDir(), "state.json"),
+		"GITHUB_ASSIGNEES_STRATEGY": "round_robin",
+		"GITHUB_ASSIGNEES_SAMPLE":   "1",
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	ctx := context.Background()
+	i := &target.Target{Number: 1, Author: "alice", Assignees: []string{}}
+	m.EXPECT().ResolveUsers(gomock.Eq(ctx), gomock.Eq([]string{"alice", "bob"})).Return([]string{"alice", "bob"}, nil)
+	m.EXPECT().SetAssignees(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq([]string{"bob"})).Return(nil)
+	if err := r.PerformAssigneesAction(ctx, i, []string{"alice", "bob"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPerformNotifyActionWithNotifiers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	ms := mock.NewMockSlkClient(ctrl)
+	r.slack = ms
+	mt := mock.NewMockNotifier(ctrl)
+	md := mock.NewMockNotifier(ctrl)
+	r.notifiers["teams"] = mt
+	r.notifiers["discord"] = md
+
+	tests := []struct {
+		in          string
+		notifierEnv string
+		wantSlack   bool
+		wantTeams   bool
+		wantDiscord bool
+		wantErr     bool
+	}{
+		{"hello", "", true, false, false, false},
+		{"hello", "teams", false, true, false, false},
+		{"hello", "slack,discord", true, false, true, false},
+		{"hello", "unknown", false, false, false, true},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		if err := os.Setenv("SLACK_API_TOKEN", "dummy"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("GHDAG_NOTIFIER", tt.notifierEnv); err != nil {
+			t.Fatal(err)
+		}
+		if tt.wantSlack {
+			ms.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Eq(tt.in)).Return(nil, nil)
+		}
+		if tt.wantTeams {
+			mt.EXPECT().Notify(gomock.Eq(ctx), gomock.Eq(&notifier.Notification{Text: tt.in, Footer: true})).Return(tt.in, nil)
+		}
+		if tt.wantDiscord {
+			md.EXPECT().Notify(gomock.Eq(ctx), gomock.Eq(&notifier.Notification{Text: tt.in, Footer: true})).Return(tt.in, nil)
+		}
+		err := r.PerformNotifyAction(ctx, nil, tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got %v\nwant error %v", err, tt.wantErr)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got := os.Getenv("GHDAG_ACTION_NOTIFY_SENT"); got != tt.in {
+			t.Errorf("got %v\nwant %v", got, tt.in)
+		}
+	}
+}
+
+func TestPerformNotifyActionWithDirectMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockSlkClient(ctrl)
+	r.slack = m
+	r.config.LinkedNames = name.LinkedNames{
+		&name.LinkedName{Github: "alice-gh", Slack: "alice"},
+	}
+
+	tests := []struct {
+		in     string
+		toEnv  string
+		wantTo []string
+	}{
+		{"hello", "alice", []string{"alice"}},
+		{"hello", "alice-gh bob", []string{"alice", "bob"}},
+		{"hello", "alice-gh alice", []string{"alice"}},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("SLACK_API_TOKEN", "dummy"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("SLACK_DIRECT_MESSAGE_TO", tt.toEnv); err != nil {
+			t.Fatal(err)
+		}
+		for _, to := range tt.wantTo {
+			m.EXPECT().PostDirectMessage(gomock.Eq(ctx), gomock.Eq(to), gomock.Eq(tt.in)).Return(nil)
+		}
+		if err := r.PerformNotifyAction(ctx, i, tt.in); err != nil {
+			t.Error(err)
+		}
+		if got := os.Getenv("GHDAG_ACTION_NOTIFY_SENT"); got != tt.in {
+			t.Errorf("got %v\nwant %v", got, tt.in)
+		}
+	}
+}
+
+func TestPerformReactionAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	tests := []struct {
+		in          string
+		behavior    string
+		commentID   int64
+		wantComment bool
+		want        string
+		wantErr     bool
+	}{
+		{"+1", "", 0, false, "+1", false},
+		{":eyes:", "", 0, false, "eyes", false},
+		{"rocket", "", 123, true, "rocket", false},
+		{"rocket", "target", 123, false, "rocket", false},
+		{"rocket", "comment", 0, false, "", true},
+		{"thumbsup", "", 0, false, "", true},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Setenv("GHDAG_ACTION_REACTION_TARGET", tt.behavior); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		r.event = &gh.GitHubEvent{
+			Name:      "issue_comment",
+			Number:    i.Number,
+			CommentID: tt.commentID,
+		}
+		if !tt.wantErr {
+			if tt.wantComment {
+				m.EXPECT().AddCommentReaction(gomock.Eq(ctx), gomock.Eq(tt.commentID), gomock.Eq(tt.want)).Return(nil)
+			} else {
+				m.EXPECT().AddReaction(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq(tt.want)).Return(nil)
+			}
+		}
+		if err := r.PerformReactionAction(ctx, i, tt.in); (err != nil) != tt.wantErr {
+			t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+		}
+		if got := os.Getenv("GHDAG_ACTION_REACTION_ADDED"); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestPerformProjectAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	tests := []struct {
+		in      *task.Project
+		current []*target.ProjectItem
+		wantErr interface{}
+	}{
+		{&task.Project{Number: 1}, nil, nil},
+		{&task.Project{Number: 1, Fields: map[string]string{"Status": "In review"}}, nil, nil},
+		{&task.Project{Number: 1, Fields: map[string]string{"Status": "In review"}}, []*target.ProjectItem{
+			{Number: 1, Fields: []*target.ProjectField{{Name: "Status", Value: "Todo"}}},
+		}, nil},
+		{&task.Project{Number: 1, Fields: map[string]string{"Status": "In review"}}, []*target.ProjectItem{
+			{Number: 1, Fields: []*target.ProjectField{{Name: "Status", Value: "In review"}}},
+		}, &erro.AlreadyInStateError{}},
+		{&task.Project{Number: 1, Fields: map[string]string{"Status": "Done"}, Archive: true}, []*target.ProjectItem{
+			{ID: "PVTI_1", Number: 1, Fields: []*target.ProjectField{{Name: "Status", Value: "Done"}}},
+		}, nil},
+		{&task.Project{Number: 1, Fields: map[string]string{"Status": "Todo"}}, []*target.ProjectItem{
+			{ID: "PVTI_1", Number: 1, IsArchived: true, Fields: []*target.ProjectField{{Name: "Status", Value: "Todo"}}},
+		}, nil},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		i.ProjectItems = tt.current
+		if tt.wantErr == nil {
+			m.EXPECT().AddProjectItem(gomock.Eq(ctx), gomock.Eq(i.Number), gomock.Eq(tt.in.Owner), gomock.Eq(tt.in.Number), gomock.Any()).Return(nil)
+			if tt.in.Archive {
+				m.EXPECT().ArchiveProjectItem(gomock.Eq(ctx), gomock.Eq(tt.in.Owner), gomock.Eq(tt.in.Number), gomock.Eq("PVTI_1")).Return(nil)
+			}
+			if len(tt.current) > 0 && tt.current[0].IsArchived && !tt.in.Archive {
+				m.EXPECT().UnarchiveProjectItem(gomock.Eq(ctx), gomock.Eq(tt.in.Owner), gomock.Eq(tt.in.Number), gomock.Eq("PVTI_1")).Return(nil)
+			}
+		}
+		if err := r.PerformProjectAction(ctx, i, tt.in); err != nil {
+			if !errors.As(err, tt.wantErr) {
+				t.Errorf("got %v\nwant %v", err, tt.wantErr)
+			}
+		}
+		if got := os.Getenv("GHDAG_ACTION_PROJECT_UPDATED"); got != fmt.Sprintf("%d", tt.in.Number) {
+			t.Errorf("got %v\nwant %v", got, tt.in.Number)
+		}
+	}
+}
+
+func TestPerformProjectActionArchiveWithoutItem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	i := &target.Target{Number: 1}
+	if err := r.PerformProjectAction(context.Background(), i, &task.Project{Number: 1, Archive: true}); err == nil {
+		t.Error("got nil\nwant error")
+	}
+}
+
+func TestPerformCreateIssueAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+
+	tests := []struct {
+		in        *task.CreateIssue
+		env       map[string]string
+		wantTitle string
+		want      string
+	}{
+		{&task.CreateIssue{Title: "Follow-up"}, map[string]string{}, "Follow-up", "10"},
+		{&task.CreateIssue{Title: "Docs for #${GHDAG_TARGET_NUMBER}", Labels: []string{"docs"}, Assignees: []string{"alice"}}, map[string]string{"GHDAG_TARGET_NUMBER": "3"}, "Docs for #3", "10"},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range tt.env {
+			if err := os.Setenv(k, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		if err := faker.FakeData(i); err != nil {
+			t.Fatal(err)
+		}
+		assignees := tt.in.Assignees
+		if assignees == nil {
+			assignees = []string{}
+		}
+		m.EXPECT().ResolveUsers(gomock.Eq(ctx), gomock.Eq(assignees)).Return(assignees, nil)
+		m.EXPECT().CreateIssue(gomock.Eq(ctx), gomock.Eq(""), gomock.Eq(tt.wantTitle), gomock.Eq(""), gomock.Any(), gomock.Eq(assignees)).Return(10, nil)
+		if err := r.PerformCreateIssueAction(ctx, i, tt.in); err != nil {
+			t.Error(err)
+		}
+		if got := os.Getenv("GHDAG_ACTION_ISSUE_CREATED"); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
 func TestSetReviewersAndNotify(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -488,7 +1328,7 @@ func TestSetReviewersAndNotify(t *testing.T) {
 		if err := os.Setenv("SLACK_MENTIONS_SAMPLE", fmt.Sprintf("%d", sample)); err != nil {
 			t.Fatal(err)
 		}
-		ms.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Any()).Return(nil)
+		ms.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Any()).Return(nil, nil)
 		for _, mu := range want {
 			ms.EXPECT().GetMentionLinkByName(gomock.Eq(ctx), gomock.Eq(mu)).Return("<UTEST>", nil)
 		}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/k1LoW/ghdag/target"
+	"github.com/k1LoW/ghdag/task"
+)
+
+// selectCodeOwnerReviewers selects reviewers from the owners of each CODEOWNERS rule that matches the changed files.
+// GITHUB_REVIEWERS_SAMPLE ( default: 1 ) reviewers are selected per rule that has not been approved yet,
+// and a rule is skipped when one of the owners has already been selected for another rule.
+func (r *Runner) selectCodeOwnerReviewers(ctx context.Context, i *target.Target, ex *task.Exclude) ([]string, error) {
+	if os.Getenv("GITHUB_REVIEWERS_SAMPLE") == "" {
+		if err := os.Setenv("GITHUB_REVIEWERS_SAMPLE", "1"); err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = os.Unsetenv("GITHUB_REVIEWERS_SAMPLE")
+		}()
+	}
+	excludeKey := r.excludeKey
+	r.excludeKey = -1
+	defer func() {
+		r.excludeKey = excludeKey
+	}()
+
+	selected := []string{}
+	for _, rule := range i.CodeOwnerRules {
+		if !contains(i.CodeOwnerRulesUnapproved, rule.Pattern) {
+			r.debuglog(fmt.Sprintf("Skip approved code owner rule: %s", rule.Pattern))
+			continue
+		}
+		owners := []string{}
+		for _, o := range rule.Owners {
+			if strings.Contains(o, "@") {
+				// email
+				continue
+			}
+			owners = append(owners, o)
+		}
+		// expand teams into members to select reviewers from them
+		candidates, err := r.github.ResolveUsers(ctx, owners)
+		if err != nil {
+			return nil, err
+		}
+		candidates = exclude(candidates, i.Author)
+		candidates = r.excludeAway(candidates)
+		candidates, err = r.excludeCandidates(ctx, i, candidates, ex)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 {
+			r.debuglog(fmt.Sprintf("No reviewer candidates for code owner rule: %s", rule.Pattern))
+			continue
+		}
+		covered := false
+		for _, c := range candidates {
+			if contains(selected, c) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		sampled, err := r.sampleByStrategy(ctx, rule.Owners, candidates, i.Author, "GITHUB_REVIEWERS")
+		if err != nil {
+			return nil, err
+		}
+		r.debuglog(fmt.Sprintf("Select reviewers for code owner rule %s: %s", rule.Pattern, sampled))
+		selected = append(selected, sampled...)
+	}
+	return unique(selected), nil
+}
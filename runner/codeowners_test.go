@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/ghdag/mock"
+	"github.com/k1LoW/ghdag/target"
+	"github.com/k1LoW/ghdag/task"
+)
+
+func TestSelectCodeOwnerReviewers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	m.EXPECT().ResolveUsers(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, in []string) ([]string, error) {
+		res := []string{}
+		for _, u := range in {
+			if u == "myorg/backend" {
+				res = append(res, "charlie", "dave")
+				continue
+			}
+			res = append(res, u)
+		}
+		return res, nil
+	}).AnyTimes()
+
+	rules := []*target.CodeOwnerRule{
+		{Pattern: "*", Owners: []string{"alice"}},
+		{Pattern: "/docs/", Owners: []string{"bob", "eve"}},
+		{Pattern: "*.go", Owners: []string{"myorg/backend", "frank@example.com"}},
+		{Pattern: "/gh/", Owners: []string{"dave"}},
+		{Pattern: "/gh/*.md", Owners: []string{"alice", "bob"}},
+	}
+
+	tests := []struct {
+		unapproved []string
+		exclude    *task.Exclude
+		want       []string
+	}{
+		{[]string{"*", "/docs/"}, nil, []string{"alice", "bob"}},
+		{[]string{}, nil, []string{}},
+		{[]string{"/docs/"}, &task.Exclude{If: `user == "bob"`}, []string{}},
+		{[]string{"/gh/"}, nil, []string{"dave"}},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		i := &target.Target{
+			Author:                   "eve",
+			CodeOwnerRules:           rules,
+			CodeOwnerRulesUnapproved: tt.unapproved,
+		}
+		got, err := r.selectCodeOwnerReviewers(ctx, i, tt.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+
+	// a rule is covered by the reviewer selected for another rule
+	i := &target.Target{
+		Author:                   "eve",
+		CodeOwnerRules:           rules,
+		CodeOwnerRulesUnapproved: []string{"*", "/gh/*.md"},
+	}
+	got, err := r.selectCodeOwnerReviewers(ctx, i, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, []string{"alice"}, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
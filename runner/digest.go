@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/k1LoW/ghdag/env"
+	"github.com/k1LoW/ghdag/task"
+)
+
+// digest is notifications collected by the `notify.digest:` key during the session
+type digest struct {
+	key      string
+	env      []string
+	entries  []*digestEntry
+	mentions []string
+}
+
+type digestEntry struct {
+	text   string
+	number string
+	title  string
+	url    string
+}
+
+// addDigest adds the notification to the digest instead of sending it
+func (r *Runner) addDigest(ctx context.Context, notify *task.Notify) error {
+	n := os.ExpandEnv(notify.Text)
+	if n == "" {
+		return fmt.Errorf("`notify.text:` is required for the digest: %s", notify.Digest)
+	}
+	mentions, err := env.Split(os.Getenv("SLACK_MENTIONS"))
+	if err != nil {
+		return err
+	}
+	mentions = r.config.LinkedNames.ToSlackNames(mentions)
+	mentions, err = r.sampleByStrategy(ctx, mentions, mentions, "", "SLACK_MENTIONS")
+	if err != nil {
+		return err
+	}
+	d, ok := r.digests[notify.Digest]
+	if !ok {
+		d = &digest{
+			key: notify.Digest,
+			env: os.Environ(),
+		}
+		r.digests[notify.Digest] = d
+		r.digestKeys = append(r.digestKeys, notify.Digest)
+	}
+	d.entries = append(d.entries, &digestEntry{
+		text:   n,
+		number: os.Getenv("GHDAG_TARGET_NUMBER"),
+		title:  os.Getenv("GHDAG_TARGET_TITLE"),
+		url:    os.Getenv("GHDAG_TARGET_URL"),
+	})
+	d.mentions = unique(append(d.mentions, mentions...))
+	r.log(fmt.Sprintf("Add notification to the digest %s: %s", notify.Digest, n))
+	return os.Setenv("GHDAG_ACTION_NOTIFY_SENT", n)
+}
+
+// sendDigests sends the aggregated notifications at the end of the session
+func (r *Runner) sendDigests(ctx context.Context) {
+	r.logPrefix = ""
+	for _, key := range r.digestKeys {
+		d := r.digests[key]
+		if err := r.sendDigest(ctx, d); err != nil {
+			r.errlog(fmt.Sprintf("failed to send the digest %s: %s", key, err))
+			r.addFailure(0, fmt.Sprintf("digest: %s", key), 0, err)
+		}
+		_ = r.revertEnv()
+	}
+	r.digests = map[string]*digest{}
+	r.digestKeys = []string{}
+}
+
+func (r *Runner) sendDigest(ctx context.Context, d *digest) error {
+	// use the environment variables of the first notification
+	if err := env.Revert(d.env); err != nil {
+		return err
+	}
+	for _, k := range []string{"SLACK_MENTIONS_SAMPLE", "SLACK_MENTIONS_STRATEGY", "SLACK_MESSAGE_KEY", "GHDAG_TARGET_NUMBER", "GHDAG_TARGET_URL", "GHDAG_TARGET_TITLE"} {
+		if err := os.Unsetenv(k); err != nil {
+			return err
+		}
+	}
+	if err := os.Setenv("SLACK_MENTIONS", env.Join(d.mentions)); err != nil {
+		return err
+	}
+	return r.PerformNotifyLayoutAction(ctx, nil, &task.Notify{Text: d.String()})
+}
+
+// String returns the aggregated message grouped by the text of notifications
+func (d *digest) String() string {
+	texts := []string{}
+	grouped := map[string][]*digestEntry{}
+	for _, e := range d.entries {
+		if _, ok := grouped[e.text]; !ok {
+			texts = append(texts, e.text)
+		}
+		grouped[e.text] = append(grouped[e.text], e)
+	}
+	lines := []string{fmt.Sprintf("*%s* ( %d )", d.key, len(d.entries))}
+	for _, t := range texts {
+		es := grouped[t]
+		lines = append(lines, fmt.Sprintf("%s ( %d )", t, len(es)))
+		for _, e := range es {
+			if e.number == "" {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("• <%s|#%s> %s", e.url, e.number, e.title))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
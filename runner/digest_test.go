@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/k1LoW/ghdag/mock"
+	"github.com/k1LoW/ghdag/task"
+)
+
+func TestDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockSlkClient(ctrl)
+	r.slack = m
+
+	ctx := context.Background()
+	targets := []struct {
+		number   string
+		title    string
+		text     string
+		mentions string
+	}{
+		{"1", "Fix bug", "Please review", "alice"},
+		{"2", "Add feature", "Please review", "alice bob"},
+		{"3", "Update docs", "Stale", ""},
+	}
+	for _, tt := range targets {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range map[string]string{
+			"SLACK_API_TOKEN":     "dummy",
+			"SLACK_MENTIONS":      tt.mentions,
+			"GHDAG_TARGET_NUMBER": tt.number,
+			"GHDAG_TARGET_TITLE":  tt.title,
+			"GHDAG_TARGET_URL":    "https://github.com/k1LoW/ghdag/pull/" + tt.number,
+		} {
+			if err := os.Setenv(k, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := r.PerformNotifyLayoutAction(ctx, nil, &task.Notify{Text: tt.text, Digest: "daily"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `<@alice> <@bob> *daily* ( 3 )
+Please review ( 2 )
+• <https://github.com/k1LoW/ghdag/pull/1|#1> Fix bug
+• <https://github.com/k1LoW/ghdag/pull/2|#2> Add feature
+Stale ( 1 )
+• <https://github.com/k1LoW/ghdag/pull/3|#3> Update docs`
+	m.EXPECT().GetMentionLinkByName(gomock.Eq(ctx), gomock.Eq("alice")).Return("<@alice>", nil)
+	m.EXPECT().GetMentionLinkByName(gomock.Eq(ctx), gomock.Eq("bob")).Return("<@bob>", nil)
+	m.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Eq(want)).Return(nil, nil)
+	r.sendDigests(ctx)
+
+	if len(r.digests) != 0 {
+		t.Errorf("got %v\nwant %v", len(r.digests), 0)
+	}
+}
+
+func TestDigestFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	m := mock.NewMockSlkClient(ctrl)
+	r.slack = m
+
+	ctx := context.Background()
+	if err := os.Setenv("SLACK_API_TOKEN", "dummy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PerformNotifyLayoutAction(ctx, nil, &task.Notify{Text: "Stale", Digest: "daily"}); err != nil {
+		t.Fatal(err)
+	}
+	m.EXPECT().PostMessage(gomock.Eq(ctx), gomock.Any()).Return(nil, errors.New("channel_not_found"))
+	r.sendDigests(ctx)
+
+	if len(r.failures) != 1 {
+		t.Errorf("got %v\nwant %v", len(r.failures), 1)
+	}
+}
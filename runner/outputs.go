@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/k1LoW/ghdag/env"
+)
+
+const outputsEnvKey = "GHDAG_ACTION_RUN_OUTPUTS"
+
+var outputsEnvKeyRe = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// parseOutputs parses the contents of the GHDAG_OUTPUT file.
+// The contents are either a JSON object or lines of `key=value` ( or `key<<DELIMITER` for multiline values ).
+func parseOutputs(b []byte) (map[string]interface{}, error) {
+	outputs := map[string]interface{}{}
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "{") {
+		if err := json.Unmarshal(b, &outputs); err != nil {
+			return nil, fmt.Errorf("invalid outputs: %w", err)
+		}
+		return outputs, nil
+	}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		l := s.Text()
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if k, v, ok := cut(l, "="); ok && !strings.Contains(k, "<<") {
+			outputs[k] = v
+			continue
+		}
+		k, delim, ok := cut(l, "<<")
+		if !ok || k == "" || delim == "" {
+			return nil, fmt.Errorf("invalid outputs line: %s", l)
+		}
+		lines := []string{}
+		closed := false
+		for s.Scan() {
+			if s.Text() == delim {
+				closed = true
+				break
+			}
+			lines = append(lines, s.Text())
+		}
+		if !closed {
+			return nil, fmt.Errorf("missing delimiter of outputs: %s", delim)
+		}
+		outputs[k] = strings.Join(lines, "\n")
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// setRunOutputs merges the outputs written to the GHDAG_OUTPUT file ( and STDOUT when GHDAG_ACTION_RUN_STDOUT_JSON is enabled )
+// into the outputs of the task
+func (r *Runner) setRunOutputs(p string, stdout []byte, succeeded bool) error {
+	outputs := map[string]interface{}{}
+	if succeeded && env.GetenvAsBool("GHDAG_ACTION_RUN_STDOUT_JSON") {
+		if err := json.Unmarshal(stdout, &outputs); err != nil {
+			return fmt.Errorf("STDOUT is not a JSON object: %w", err)
+		}
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	fo, err := parseOutputs(b)
+	if err != nil {
+		return err
+	}
+	for k, v := range fo {
+		outputs[k] = v
+	}
+	if len(outputs) == 0 {
+		return nil
+	}
+	return setOutputs(outputs)
+}
+
+// setOutputs merges the outputs into GHDAG_ACTION_RUN_OUTPUTS and sets them to GHDAG_OUTPUTS_<KEY> environment variables
+func setOutputs(outputs map[string]interface{}) error {
+	merged := currentOutputs()
+	for k, v := range outputs {
+		merged[k] = v
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if err := os.Setenv(outputsEnvKey, string(b)); err != nil {
+		return err
+	}
+	for k, v := range merged {
+		ek := fmt.Sprintf("GHDAG_OUTPUTS_%s", outputsEnvKeyRe.ReplaceAllString(strings.ToUpper(k), "_"))
+		ev, ok := v.(string)
+		if !ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			ev = string(b)
+		}
+		if err := os.Setenv(ek, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentOutputs returns the outputs of the task ( including the outputs of the caller task )
+func currentOutputs() map[string]interface{} {
+	outputs := map[string]interface{}{}
+	if v := os.Getenv(outputsEnvKey); v != "" {
+		_ = json.Unmarshal([]byte(v), &outputs)
+	}
+	return outputs
+}
+
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
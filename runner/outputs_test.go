@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/ghdag/target"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"", map[string]interface{}{}, false},
+		{"foo=bar\n\nbaz=a=b\n", map[string]interface{}{"foo": "bar", "baz": "a=b"}, false},
+		{"body<<EOF\nline1\nline2\nEOF\nfoo=bar\n", map[string]interface{}{"body": "line1\nline2", "foo": "bar"}, false},
+		{`{"foo": "bar", "count": 2, "ok": true}`, map[string]interface{}{"foo": "bar", "count": float64(2), "ok": true}, false},
+		{"body<<EOF\nline1\n", nil, true},
+		{"invalid\n", nil, true},
+		{`{"foo": }`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseOutputs([]byte(tt.in))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+}
+
+func TestPerformRunActionOutputs(t *testing.T) {
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	tests := []struct {
+		commands   []string
+		stdoutJSON bool
+		cond       string
+		wantEnv    map[string]string
+	}{
+		{[]string{`echo "foo=bar" >> $GHDAG_OUTPUT`}, false, `outputs.foo == "bar"`, map[string]string{"GHDAG_OUTPUTS_FOO": "bar"}},
+		{[]string{`echo "foo=bar" >> $GHDAG_OUTPUT`, `echo "foo=baz" >> $GHDAG_OUTPUT; echo "my-key=1" >> $GHDAG_OUTPUT`}, false, `outputs.foo == "baz" && outputs["my-key"] == "1"`, map[string]string{"GHDAG_OUTPUTS_FOO": "baz", "GHDAG_OUTPUTS_MY_KEY": "1"}},
+		{[]string{`echo '{"count": 3, "items": ["a", "b"]}'`}, true, `outputs.count == 3 && "b" in outputs.items`, map[string]string{"GHDAG_OUTPUTS_COUNT": "3", "GHDAG_OUTPUTS_ITEMS": `["a","b"]`}},
+		{[]string{`echo '{"count": 3}'; echo "count=4" >> $GHDAG_OUTPUT`}, true, `outputs.count == "4"`, map[string]string{"GHDAG_OUTPUTS_COUNT": "4"}},
+		{[]string{`echo '{"count": 3}'`}, false, `outputs.count == nil`, map[string]string{}},
+	}
+	for _, tt := range tests {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+		if tt.stdoutJSON {
+			if err := os.Setenv("GHDAG_ACTION_RUN_STDOUT_JSON", "true"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ctx := context.Background()
+		i := &target.Target{}
+		for _, c := range tt.commands {
+			if err := r.PerformRunAction(ctx, i, c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !r.CheckIf(tt.cond, i) {
+			t.Errorf("%v: got false want true (%s)", tt.commands, tt.cond)
+		}
+		for k, want := range tt.wantEnv {
+			if got := os.Getenv(k); got != want {
+				t.Errorf("%s: got %v\nwant %v", k, got, want)
+			}
+		}
+	}
+
+	// STDOUT is not JSON
+	if err := r.revertEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("GHDAG_ACTION_RUN_STDOUT_JSON", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PerformRunAction(context.Background(), &target.Target{}, "echo hello"); err == nil {
+		t.Error("got nil want error")
+	}
+
+	// invalid outputs are retried
+	if err := r.revertEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("GHDAG_ACTION_RUN_RETRY_MAX", "1"); err != nil {
+		t.Fatal(err)
+	}
+	tmp := t.TempDir()
+	if err := os.Setenv("TMPDIR", tmp); err != nil {
+		t.Fatal(err)
+	}
+	tried := filepath.Join(tmp, "tried")
+	c := fmt.Sprintf(`if [ -e %s ]; then echo "foo=bar" >> $GHDAG_OUTPUT; else touch %s; echo "invalid" >> $GHDAG_OUTPUT; fi`, tried, tried)
+	i := &target.Target{}
+	if err := r.PerformRunAction(context.Background(), i, c); err != nil {
+		t.Fatal(err)
+	}
+	if !r.CheckIf(`outputs.foo == "bar"`, i) {
+		t.Error("got false want true")
+	}
+	// the GHDAG_OUTPUT files are removed after each attempt
+	files, err := filepath.Glob(filepath.Join(tmp, "ghdag_output*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) > 0 {
+		t.Errorf("got %v\nwant no files", files)
+	}
+}
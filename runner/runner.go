@@ -14,26 +14,39 @@ import (
 	"time"
 
 	"github.com/antonmedv/expr"
+	"github.com/k1LoW/duration"
 	"github.com/k1LoW/ghdag/config"
 	"github.com/k1LoW/ghdag/env"
 	"github.com/k1LoW/ghdag/erro"
 	"github.com/k1LoW/ghdag/gh"
+	"github.com/k1LoW/ghdag/notifier"
 	"github.com/k1LoW/ghdag/slk"
+	"github.com/k1LoW/ghdag/store"
 	"github.com/k1LoW/ghdag/target"
 	"github.com/k1LoW/ghdag/task"
 	"github.com/rs/zerolog/log"
 )
 
 type Runner struct {
-	mu         sync.Mutex
-	config     *config.Config
-	github     gh.GhClient
-	slack      slk.SlkClient
-	event      *gh.GitHubEvent
-	envCache   []string
-	logPrefix  string
-	seed       int64
-	excludeKey int
+	mu          sync.Mutex
+	config      *config.Config
+	github      gh.GhClient
+	slack       slk.SlkClient
+	notifiers   map[string]notifier.Notifier
+	digests     map[string]*digest
+	allTargets  target.Targets
+	assigned    map[string]map[string]int
+	store       *store.Store
+	digestKeys  []string
+	results     map[int]map[string]string
+	failures    []*failure
+	event       *gh.GitHubEvent
+	envCache    []string
+	logPrefix   string
+	seed        int64
+	seedSource  *rand.Rand
+	sessionSeed int64
+	excludeKey  int
 }
 
 func New(c *config.Config) (*Runner, error) {
@@ -41,16 +54,30 @@ func New(c *config.Config) (*Runner, error) {
 	if c == nil {
 		c = config.New()
 	}
-	return &Runner{
+	r := &Runner{
 		config:     c,
 		github:     nil,
 		slack:      nil,
+		notifiers:  map[string]notifier.Notifier{},
+		digests:    map[string]*digest{},
+		digestKeys: []string{},
+		assigned:   map[string]map[string]int{},
+		results:    map[int]map[string]string{},
 		event:      e,
 		envCache:   os.Environ(),
 		logPrefix:  "",
-		seed:       time.Now().UnixNano(),
 		excludeKey: -1,
-	}, nil
+	}
+	r.SetSeed(time.Now().UnixNano())
+	return r, nil
+}
+
+// SetSeed sets the seed of the session. The seeds for sampling are derived from it,
+// so a session can be reproduced with the same seed.
+func (r *Runner) SetSeed(seed int64) {
+	r.sessionSeed = seed
+	r.seed = seed
+	r.seedSource = rand.New(rand.NewSource(seed))
 }
 
 type TaskQueue struct {
@@ -67,6 +94,7 @@ func (r *Runner) Run(ctx context.Context) error {
 	r.logPrefix = ""
 	r.log("Start session")
 	r.log(fmt.Sprintf("github.event_name: %s", r.event.Name))
+	r.log(fmt.Sprintf("seed: %d", r.sessionSeed))
 	defer func() {
 		_ = r.revertEnv()
 		r.logPrefix = ""
@@ -80,22 +108,39 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
+	if r.config.Tasks.UsesCodeOwnerRules() {
+		if err := os.Setenv("GHDAG_FETCH_CODE_OWNER_RULES", "true"); err != nil {
+			return err
+		}
+	}
+
 	targets, err := r.fetchTargets(ctx)
 	maxDigits := targets.MaxDigits()
 	r.log(fmt.Sprintf("%d issues and pull requests are fetched", len(targets)))
 	if errors.As(err, &erro.NotOpenError{}) {
 		r.log(fmt.Sprintf("[SKIP] %s", err))
+		if r.event.Number > 0 {
+			r.deleteSlackMessages(ctx, r.event.Number)
+		}
 		return nil
 	}
 	if err != nil {
 		return err
 	}
-	tasks := r.config.Tasks
+	tasks, err := r.config.Tasks.Sorted()
+	if err != nil {
+		return err
+	}
 	r.log(fmt.Sprintf("%d tasks are loaded", len(tasks)))
 	maxLength := tasks.MaxLengthID()
 
+	sortedTargets, err := targets.Sorted(os.Getenv("GHDAG_TARGETS_ORDER"))
+	if err != nil {
+		return err
+	}
+
 	q := make(chan TaskQueue, len(tasks)*len(targets)+100)
-	for _, i := range targets {
+	for _, i := range sortedTargets {
 		for _, t := range tasks {
 			q <- TaskQueue{
 				target: i,
@@ -151,8 +196,19 @@ func (r *Runner) Run(ctx context.Context) error {
 				r.excludeKey = tq.callerExcludeKey
 			}
 
-			if tq.task.If != "" {
-				if !r.CheckIf(tq.task.If, tq.target) {
+			cond := tq.task.If
+			if len(tq.task.Needs) > 0 {
+				if err := os.Setenv("GHDAG_NEEDS_RESULT", r.needsResult(n, tq.task.Needs)); err != nil {
+					return err
+				}
+				if cond == "" {
+					cond = fmt.Sprintf("needs_result == %q", resultOk)
+				}
+			}
+
+			if cond != "" {
+				if !r.CheckIf(cond, tq.target) {
+					r.setResult(n, id, resultSkipped)
 					return nil
 				}
 			} else {
@@ -163,45 +219,129 @@ func (r *Runner) Run(ctx context.Context) error {
 			}
 
 			r.logPrefix = fmt.Sprintf(fmt.Sprintf("[#%%-%dd << %%-%ds] [DO] ", maxDigits, maxLength), n, id)
-			if err := r.perform(ctx, tq.task.Do, tq.target, tq.task, q); err == nil {
+			err := r.performWithTimeout(ctx, tq.task.Do, tq.target, tq.task, q)
+			if errors.As(err, &erro.AlreadyInStateError{}) {
+				r.setResult(n, id, resultOk)
+				r.log(fmt.Sprintf("[SKIP] %s", err))
+				return nil
+			}
+			if errors.As(err, &erro.NoReviewerError{}) {
+				r.setResult(n, id, resultSkipped)
+				r.log(fmt.Sprintf("[SKIP] %s", err))
+				return nil
+			}
+			if err != nil {
+				r.errlog(fmt.Sprintf("%s", err))
+				if err := os.Setenv("GHDAG_ACTION_DO_ERROR", fmt.Sprintf("%s", err)); err != nil {
+					return err
+				}
+				if tq.task.ContinueOnError {
+					r.log("[CONTINUE] `continue_on_error:` is enabled")
+					err = nil
+				} else {
+					r.addFailure(n, id, task.ActionTypeDo, err)
+				}
+			}
+			if err == nil {
+				r.setResult(n, id, resultOk)
 				r.logPrefix = fmt.Sprintf(fmt.Sprintf("[#%%-%dd << %%-%ds] [OK] ", maxDigits, maxLength), n, id)
-				if err := r.perform(ctx, tq.task.Ok, tq.target, tq.task, q); err != nil {
+				if err := r.performWithTimeout(ctx, tq.task.Ok, tq.target, tq.task, q); err != nil {
 					r.initSeed()
 					if errors.As(err, &erro.AlreadyInStateError{}) || errors.As(err, &erro.NoReviewerError{}) {
 						r.log(fmt.Sprintf("[SKIP] %s", err))
 						return nil
 					}
 					r.errlog(fmt.Sprintf("%s", err))
+					if tq.task.OnError == task.OnErrorAbort {
+						return err
+					}
+					r.addFailure(n, id, task.ActionTypeOk, err)
 					return nil
 				}
-			} else {
+				return nil
+			}
+
+			r.setResult(n, id, resultNg)
+			switch tq.task.OnError {
+			case task.OnErrorAbort:
+				return err
+			case task.OnErrorSkip:
+				r.log("[SKIP] `on_error:` is skip")
+				return nil
+			}
+			r.logPrefix = fmt.Sprintf(fmt.Sprintf("[#%%-%dd << %%-%ds] [NG] ", maxDigits, maxLength), n, id)
+			if err := r.performWithTimeout(ctx, tq.task.Ng, tq.target, tq.task, q); err != nil {
 				if errors.As(err, &erro.AlreadyInStateError{}) || errors.As(err, &erro.NoReviewerError{}) {
 					r.log(fmt.Sprintf("[SKIP] %s", err))
 					return nil
 				}
 				r.errlog(fmt.Sprintf("%s", err))
-				if err := os.Setenv("GHDAG_ACTION_DO_ERROR", fmt.Sprintf("%s", err)); err != nil {
+				if tq.task.OnError == task.OnErrorAbort {
 					return err
 				}
-				r.logPrefix = fmt.Sprintf(fmt.Sprintf("[#%%-%dd << %%-%ds] [NG] ", maxDigits, maxLength), n, id)
-				if err := r.perform(ctx, tq.task.Ng, tq.target, tq.task, q); err != nil {
-					if errors.As(err, &erro.AlreadyInStateError{}) || errors.As(err, &erro.NoReviewerError{}) {
-						r.log(fmt.Sprintf("[SKIP] %s", err))
-						return nil
-					}
-					r.errlog(fmt.Sprintf("%s", err))
-					return nil
-				}
+				r.addFailure(n, id, task.ActionTypeNg, err)
+				return nil
 			}
 			return nil
 		}()
 		if err != nil {
-			return err
+			if tq.task.OnError == task.OnErrorAbort {
+				// send the digests collected so far
+				r.sendDigests(ctx)
+				r.logPrefix = ""
+				r.logSummary()
+				return err
+			}
+			// isolate the failure of the target and continue with the others
+			r.errlog(fmt.Sprintf("%s", err))
+			r.setResult(tq.target.Number, tq.task.Id, resultNg)
+			r.addFailure(tq.target.Number, tq.task.Id, 0, err)
 		}
 	}
+	r.sendDigests(ctx)
+	r.logPrefix = ""
+	r.logSummary()
+	if len(r.failures) > 0 {
+		return erro.NewFailedError(fmt.Errorf("%d failures in the session", len(r.failures)))
+	}
 	return nil
 }
 
+// failure is a failed action ( or task ) for the target
+type failure struct {
+	number     int
+	taskID     string
+	actionType task.ActionType
+	err        error
+}
+
+func (r *Runner) addFailure(n int, id string, t task.ActionType, err error) {
+	r.failures = append(r.failures, &failure{number: n, taskID: id, actionType: t, err: err})
+}
+
+// logSummary logs the summary of the results and the failures of the session
+func (r *Runner) logSummary() {
+	counts := map[string]int{}
+	for _, rs := range r.results {
+		for _, result := range rs {
+			counts[result]++
+		}
+	}
+	r.log(fmt.Sprintf("Summary: %d ok, %d ng, %d skipped, %d failures", counts[resultOk], counts[resultNg], counts[resultSkipped], len(r.failures)))
+	for _, f := range r.failures {
+		if f.number == 0 {
+			// not for a target ( ex. digest )
+			r.errlog(fmt.Sprintf("[%s] %s", f.taskID, f.err))
+			continue
+		}
+		if f.actionType == 0 {
+			r.errlog(fmt.Sprintf("[#%d << %s] %s", f.number, f.taskID, f.err))
+			continue
+		}
+		r.errlog(fmt.Sprintf("[#%d << %s] [%s] %s", f.number, f.taskID, strings.ToUpper(f.actionType.String()), f.err))
+	}
+}
+
 func (r *Runner) InitClients() error {
 	if r.github == nil {
 		gc, err := gh.NewClient()
@@ -215,31 +355,78 @@ func (r *Runner) InitClients() error {
 		if err != nil {
 			return err
 		}
+		// share the state file with the round_robin strategy
+		if err := r.openStore(); err != nil {
+			return err
+		}
+		sc.SetStore(r.store)
 		r.slack = sc
 	}
 	return nil
 }
 
+// deleteSlackMessages deletes the Slack messages sent with SLACK_MESSAGE_KEY for the closed target,
+// and forgets the messages and the threads of the target in the state file.
+// SLACK_DELETE_MESSAGES_ON_CLOSE should be set in the top-level `env:` because no task `env:` is applied when the workflow is triggered by closing the target.
+// The target has already been closed, so a failure is only logged.
+func (r *Runner) deleteSlackMessages(ctx context.Context, n int) {
+	if env.GetenvAsBool("SLACK_DELETE_MESSAGES_ON_CLOSE") {
+		r.log(fmt.Sprintf("Delete Slack messages of #%d", n))
+		if err := r.slack.DeleteMessages(ctx, n); err != nil {
+			r.errlog(fmt.Sprintf("failed to delete Slack messages of #%d: %s", n, err))
+		}
+	}
+	if err := r.slack.ForgetMessages(n); err != nil {
+		r.errlog(fmt.Sprintf("failed to forget Slack messages of #%d: %s", n, err))
+	}
+}
+
 func (r *Runner) CheckIf(cond string, i *target.Target) bool {
 	if cond == "" {
 		return false
 	}
+	variables := r.variables(i)
+
+	if env.GetenvAsBool("DEBUG") {
+		v, _ := json.MarshalIndent(variables, "", "  ")
+		r.debuglog(fmt.Sprintf("variables of `if:` section:\n%s", v))
+	}
+
+	doOrNot, err := expr.Eval(fmt.Sprintf("(%s) == true", cond), variables)
+	if err != nil {
+		r.errlog(fmt.Sprintf("%s", err))
+		return false
+	}
+	if !doOrNot.(bool) {
+		r.debuglog(fmt.Sprintf("[SKIP] the condition in the `if` section is not met (%s)", cond))
+		return false
+	}
+	return true
+}
+
+// variables returns the variables available in expressions
+func (r *Runner) variables(i *target.Target) map[string]interface{} {
 	isCalled := env.GetenvAsBool("GHDAG_TASK_IS_CALLED")
 	now := time.Now()
 	variables := map[string]interface{}{
-		"year":      now.UTC().Year(),
-		"month":     now.UTC().Month(),
-		"day":       now.UTC().Day(),
-		"hour":      now.UTC().Hour(),
-		"weekday":   int(now.UTC().Weekday()),
-		"is_called": isCalled,
+		"year":         now.UTC().Year(),
+		"month":        now.UTC().Month(),
+		"day":          now.UTC().Day(),
+		"hour":         now.UTC().Hour(),
+		"weekday":      int(now.UTC().Weekday()),
+		"is_called":    isCalled,
+		"needs_result": os.Getenv("GHDAG_NEEDS_RESULT"),
 		"github": map[string]interface{}{
 			"event_name": r.event.Name,
 			"event":      r.event.Payload,
 		},
-		"env": env.EnvMap(),
+		"env":     env.EnvMap(),
+		"outputs": currentOutputs(),
 	}
 	for _, k := range propagatableEnv {
+		if k == outputsEnvKey {
+			continue
+		}
 		v := os.Getenv(k)
 		key := strings.ToLower(strings.Replace(k, "GHDAG_", "CALLER_", 1))
 		switch k {
@@ -250,23 +437,7 @@ func (r *Runner) CheckIf(cond string, i *target.Target) bool {
 			variables[key] = v
 		}
 	}
-	variables = merge(variables, i.Dump())
-
-	if env.GetenvAsBool("DEBUG") {
-		v, _ := json.MarshalIndent(variables, "", "  ")
-		r.debuglog(fmt.Sprintf("variables of `if:` section:\n%s", v))
-	}
-
-	doOrNot, err := expr.Eval(fmt.Sprintf("(%s) == true", cond), variables)
-	if err != nil {
-		r.errlog(fmt.Sprintf("%s", err))
-		return false
-	}
-	if !doOrNot.(bool) {
-		r.debuglog(fmt.Sprintf("[SKIP] the condition in the `if` section is not met (%s)", cond))
-		return false
-	}
-	return true
+	return merge(variables, i.Dump())
 }
 
 func (r *Runner) perform(ctx context.Context, a *task.Action, i *target.Target, t *task.Task, q chan TaskQueue) error {
@@ -280,35 +451,103 @@ func (r *Runner) perform(ctx context.Context, a *task.Action, i *target.Target,
 		return r.PerformRunAction(ctx, i, a.Run)
 	case len(a.Labels) > 0:
 		return r.PerformLabelsAction(ctx, i, a.Labels)
-	case len(a.Assignees) > 0 || (a.Assignees != nil && os.Getenv("GITHUB_ASSIGNEES") != ""):
+	case a.Assignees != nil && (len(a.Assignees.Users) > 0 || os.Getenv("GITHUB_ASSIGNEES") != ""):
 		as, err := env.Split(os.Getenv("GITHUB_ASSIGNEES"))
 		if err != nil {
 			return err
 		}
-		assignees := unique(append(a.Assignees, as...))
-		return r.PerformAssigneesAction(ctx, i, assignees)
-	case len(a.Reviewers) > 0 || (a.Reviewers != nil && os.Getenv("GITHUB_REVIEWERS") != ""):
+		assignees := &task.Candidates{
+			Users:   unique(append(a.Assignees.Users, as...)),
+			Exclude: a.Assignees.Exclude,
+		}
+		return r.PerformAssigneesCandidatesAction(ctx, i, assignees)
+	case a.Reviewers != nil && a.Reviewers.From != "":
+		return r.PerformReviewersCandidatesAction(ctx, i, a.Reviewers)
+	case a.Reviewers != nil && (len(a.Reviewers.Users) > 0 || os.Getenv("GITHUB_REVIEWERS") != ""):
 		rs, err := env.Split(os.Getenv("GITHUB_REVIEWERS"))
 		if err != nil {
 			return err
 		}
-		reviewers := unique(append(a.Reviewers, rs...))
-		return r.PerformReviewersAction(ctx, i, reviewers)
+		reviewers := &task.Candidates{
+			Users:   unique(append(a.Reviewers.Users, rs...)),
+			Exclude: a.Reviewers.Exclude,
+		}
+		return r.PerformReviewersCandidatesAction(ctx, i, reviewers)
 	case a.Comment != "":
 		return r.PerformCommentAction(ctx, i, a.Comment)
 	case a.State != "":
 		return r.PerformStateAction(ctx, i, a.State)
-	case a.Notify != "":
-		return r.PerformNotifyAction(ctx, i, a.Notify)
+	case a.Notify != nil:
+		return r.PerformNotifyLayoutAction(ctx, i, a.Notify)
+	case a.Reaction != "":
+		return r.PerformReactionAction(ctx, i, a.Reaction)
+	case a.Project != nil:
+		return r.PerformProjectAction(ctx, i, a.Project)
+	case a.CreateIssue != nil:
+		return r.PerformCreateIssueAction(ctx, i, a.CreateIssue)
+	case a.UpdateBranch != "":
+		return r.PerformUpdateBranchAction(ctx, i, a.UpdateBranch)
+	case a.AutoMerge != "":
+		return r.PerformAutoMergeAction(ctx, i, a.AutoMerge)
 	case len(a.Next) > 0:
 		return r.performNextAction(ctx, i, t, q, a.Next)
 	}
 	return nil
 }
 
+const (
+	resultOk      = "ok"
+	resultNg      = "ng"
+	resultSkipped = "skipped"
+)
+
+// setResult records the result of the task for the target to be referred by `needs:`
+func (r *Runner) setResult(n int, id, result string) {
+	if _, ok := r.results[n]; !ok {
+		r.results[n] = map[string]string{}
+	}
+	r.results[n][id] = result
+}
+
+// needsResult returns the aggregated result of the needed tasks for the target.
+// It is `ng` if any of them failed, `skipped` if any of them did not run, otherwise `ok`.
+func (r *Runner) needsResult(n int, needs []string) string {
+	result := resultOk
+	for _, id := range needs {
+		switch r.results[n][id] {
+		case resultOk:
+		case resultNg:
+			return resultNg
+		default:
+			result = resultSkipped
+		}
+	}
+	return result
+}
+
+// performWithTimeout performs the action within `timeout:` of the task
+func (r *Runner) performWithTimeout(ctx context.Context, a *task.Action, i *target.Target, t *task.Task, q chan TaskQueue) error {
+	if t.Timeout == "" {
+		return r.perform(ctx, a, i, t, q)
+	}
+	d, err := duration.Parse(t.Timeout)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	if err := r.perform(ctx, a, i, t, q); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timeout ( %s ): %w", t.Timeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
 func (r *Runner) initSeed() {
 	if !env.GetenvAsBool("GHDAG_SAMPLE_WITH_SAME_SEED") {
-		r.seed = time.Now().UnixNano()
+		r.seed = r.seedSource.Int63()
 		r.excludeKey = -1
 	}
 }
@@ -338,7 +577,23 @@ func (r *Runner) initTaskEnv(tq TaskQueue) error {
 		case []interface{}:
 			ev := []string{}
 			for _, i := range v {
-				ev = append(ev, i.(string))
+				s, ok := i.(string)
+				if !ok {
+					ev = nil
+					break
+				}
+				ev = append(ev, s)
+			}
+			if ev == nil {
+				// ex. project_items
+				b, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+				if err := os.Setenv(ek, string(b)); err != nil {
+					return err
+				}
+				continue
 			}
 			if err := os.Setenv(ek, strings.Join(ev, ", ")); err != nil {
 				return err
@@ -368,6 +623,12 @@ func (r *Runner) initTaskEnv(tq TaskQueue) error {
 		if err := tq.callerEnv.Setenv(); err != nil {
 			return err
 		}
+		if os.Getenv(outputsEnvKey) != "" {
+			// outputs of the caller task
+			if err := setOutputs(map[string]interface{}{}); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -382,7 +643,12 @@ func (r *Runner) fetchTargets(ctx context.Context) (target.Targets, error) {
 		return target.Targets{t.Number: t}, nil
 	}
 	r.log(fmt.Sprintf("Fetch all open issues and pull requests from %s", os.Getenv("GITHUB_REPOSITORY")))
-	return r.github.FetchTargets(ctx)
+	targets, err := r.github.FetchTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.allTargets = targets
+	return targets, nil
 }
 
 func (r *Runner) FetchTarget(ctx context.Context, n int) (*target.Target, error) {
@@ -423,8 +689,7 @@ func (r *Runner) sample(in []string, envKey string) ([]string, error) {
 	}
 
 	if len(in) > sn {
-		rand.Seed(r.seed)
-		rand.Shuffle(len(in), func(i, j int) { in[i], in[j] = in[j], in[i] })
+		rand.New(rand.NewSource(r.seed)).Shuffle(len(in), func(i, j int) { in[i], in[j] = in[j], in[i] })
 		in = in[:sn]
 	}
 	return in, nil
@@ -1,15 +1,22 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/bxcodec/faker/v3"
+	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/k1LoW/ghdag/env"
+	"github.com/k1LoW/ghdag/erro"
+	"github.com/k1LoW/ghdag/mock"
 	"github.com/k1LoW/ghdag/target"
+	"github.com/k1LoW/ghdag/task"
 )
 
 func TestCheckIf(t *testing.T) {
@@ -180,6 +187,101 @@ func TestSampleByEnvWithSameSeed(t *testing.T) {
 	}
 }
 
+func TestSampleWithSeed(t *testing.T) {
+	envKey := "TEST_SAMPLE_BY_ENV"
+	os.Setenv(envKey, "3")
+	defer os.Unsetenv(envKey)
+	in := []string{}
+	for i := 0; i < 100; i++ {
+		in = append(in, fmt.Sprintf("%d", i))
+	}
+	samples := func(seed int64) [][]string {
+		r, err := New(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.SetSeed(seed)
+		res := [][]string{}
+		for i := 0; i < 3; i++ {
+			r.initSeed()
+			got, err := r.sample(append([]string{}, in...), envKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res = append(res, got)
+		}
+		return res
+	}
+	got := samples(1)
+	if diff := cmp.Diff(got, samples(1), nil); diff != "" {
+		t.Errorf("same seed should reproduce the samples: %s", diff)
+	}
+	if diff := cmp.Diff(got, samples(2), nil); diff == "" {
+		t.Error("different seeds should produce different samples")
+	}
+}
+
+func TestRunWithTargetsOrder(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		order   string
+		want    string
+		wantErr bool
+	}{
+		{"", "1\n2\n3\n", false},
+		{"number", "1\n2\n3\n", false},
+		{"created", "3\n1\n2\n", false},
+		{"updated", "2\n1\n3\n", false},
+		{"invalid", "", true},
+	}
+	for _, tt := range tests {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			r, err := New(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := r.revertEnv(); err != nil {
+					t.Fatal(err)
+				}
+			}()
+			if err := os.Setenv("GITHUB_EVENT_NAME", "schedule"); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Setenv("GHDAG_TARGETS_ORDER", tt.order); err != nil {
+				t.Fatal(err)
+			}
+			m := mock.NewMockGhClient(ctrl)
+			r.github = m
+			r.slack = mock.NewMockSlkClient(ctrl)
+			p := filepath.Join(t.TempDir(), "order")
+			r.config.Tasks = task.Tasks{
+				{Id: "record", If: "true", Do: &task.Action{Run: fmt.Sprintf("echo ${GHDAG_TARGET_NUMBER} >> %s", p)}},
+			}
+			m.EXPECT().FetchTargets(gomock.Any()).Return(target.Targets{
+				1: &target.Target{Number: 1, CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now.Add(-2 * time.Hour)},
+				2: &target.Target{Number: 2, CreatedAt: now.Add(-1 * time.Hour), UpdatedAt: now.Add(-3 * time.Hour)},
+				3: &target.Target{Number: 3, CreatedAt: now.Add(-3 * time.Hour), UpdatedAt: now.Add(-1 * time.Hour)},
+			}, nil)
+			if err := r.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("%s: got %v\nwantErr %v", tt.order, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			b, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tt.want {
+				t.Errorf("%s: got %q\nwant %q", tt.order, got, tt.want)
+			}
+		}()
+	}
+}
+
 func TestUnique(t *testing.T) {
 	tests := []struct {
 		in   []string
@@ -203,3 +305,194 @@ func testdataDir() string {
 	dir, _ := filepath.Abs(filepath.Join(filepath.Dir(wd), "testdata"))
 	return dir
 }
+
+func TestRunWithNeeds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := os.Setenv("GITHUB_EVENT_NAME", "schedule"); err != nil {
+		t.Fatal(err)
+	}
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	r.slack = mock.NewMockSlkClient(ctrl)
+	r.config.Tasks = task.Tasks{
+		{Id: "c", Needs: []string{"b"}, If: `needs_result == "ng"`, Do: &task.Action{Run: "exit 0"}},
+		{Id: "d", Needs: []string{"a", "b"}, Do: &task.Action{Run: "exit 0"}},
+		{Id: "b", Needs: []string{"a"}, Do: &task.Action{Run: "exit 1"}},
+		{Id: "a", If: "true", Do: &task.Action{Run: "exit 0"}},
+		{Id: "e", Needs: []string{"a"}, Do: &task.Action{Run: "exit 0"}},
+	}
+	m.EXPECT().FetchTargets(gomock.Any()).Return(target.Targets{1: &target.Target{Number: 1}}, nil)
+
+	if err := r.Run(context.Background()); !errors.As(err, &erro.FailedError{}) {
+		t.Errorf("got %v\nwant %v", err, erro.FailedError{})
+	}
+	want := map[string]string{
+		"a": "ok",
+		"b": "ng",
+		"c": "ok",
+		"d": "skipped",
+		"e": "ok",
+	}
+	if diff := cmp.Diff(r.results[1], want, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestRunWithNeedsCalledAgain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := os.Setenv("GITHUB_EVENT_NAME", "schedule"); err != nil {
+		t.Fatal(err)
+	}
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	r.slack = mock.NewMockSlkClient(ctrl)
+	r.config.Tasks = task.Tasks{
+		{Id: "a", If: "true", Do: &task.Action{Run: `test "$GHDAG_TASK_IS_CALLED" = "1"`}},
+		{Id: "b", Needs: []string{"a"}, If: `needs_result == "ng"`, Do: &task.Action{Run: "exit 0"}},
+		{Id: "c", If: "true", Do: &task.Action{Next: []string{"a"}}},
+	}
+	m.EXPECT().FetchTargets(gomock.Any()).Return(target.Targets{1: &target.Target{Number: 1}}, nil)
+	m.EXPECT().FetchTarget(gomock.Any(), 1).Return(&target.Target{Number: 1}, nil)
+
+	if err := r.Run(context.Background()); !errors.As(err, &erro.FailedError{}) {
+		t.Errorf("got %v\nwant %v", err, erro.FailedError{})
+	}
+	// b uses the result of the first run of a, and is not re-evaluated when a is called via next:
+	want := map[string]string{
+		"a": "ok",
+		"b": "ok",
+		"c": "ok",
+	}
+	if diff := cmp.Diff(r.results[1], want, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestRunWithOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := os.Setenv("GITHUB_EVENT_NAME", "schedule"); err != nil {
+		t.Fatal(err)
+	}
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	r.slack = mock.NewMockSlkClient(ctrl)
+	dir := t.TempDir()
+	touch := func(name string) *task.Action {
+		return &task.Action{Run: fmt.Sprintf("touch %s", filepath.Join(dir, name))}
+	}
+	fail := &task.Action{Run: "exit 1"}
+	r.config.Tasks = task.Tasks{
+		{Id: "skip", If: "true", Do: fail, Ng: touch("skip_ng"), OnError: task.OnErrorSkip},
+		{Id: "ng", If: "true", Do: fail, Ng: touch("ng_ng")},
+		{Id: "continue", If: "true", Do: fail, Ok: touch("continue_ok"), ContinueOnError: true},
+		{Id: "timeout", If: "true", Do: &task.Action{Run: "sleep 5"}, Ng: touch("timeout_ng"), Timeout: "1 sec"},
+		{Id: "abort", If: "true", Do: fail, Ng: touch("abort_ng"), OnError: task.OnErrorAbort},
+		{Id: "after", If: "true", Do: touch("after_do")},
+	}
+	m.EXPECT().FetchTargets(gomock.Any()).Return(target.Targets{1: &target.Target{Number: 1}}, nil)
+
+	if err := r.Run(context.Background()); err == nil {
+		t.Error("want error")
+	}
+	want := map[string]string{
+		"skip":     "ng",
+		"ng":       "ng",
+		"continue": "ok",
+		"timeout":  "ng",
+		"abort":    "ng",
+	}
+	if diff := cmp.Diff(r.results[1], want, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+	for f, want := range map[string]bool{
+		"skip_ng":     false,
+		"ng_ng":       true,
+		"continue_ok": true,
+		"timeout_ng":  true,
+		"abort_ng":    false,
+		"after_do":    false,
+	} {
+		_, err := os.Stat(filepath.Join(dir, f))
+		if got := err == nil; got != want {
+			t.Errorf("%s: got %v\nwant %v", f, got, want)
+		}
+	}
+}
+
+func TestRunWithFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := os.Setenv("GITHUB_EVENT_NAME", "schedule"); err != nil {
+		t.Fatal(err)
+	}
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	r.slack = mock.NewMockSlkClient(ctrl)
+	dir := t.TempDir()
+	r.config.Tasks = task.Tasks{
+		{Id: "fail", If: "number == 1", Do: &task.Action{Run: "exit 1"}, Ng: &task.Action{Run: "exit 0"}},
+		{Id: "call", If: "number == 2", Do: &task.Action{Next: []string{"called"}}},
+		{Id: "called", Do: &task.Action{Run: "exit 0"}},
+		{Id: "after", If: "true", Do: &task.Action{Run: fmt.Sprintf("touch %s/after_${GHDAG_TARGET_NUMBER}", dir)}},
+	}
+	m.EXPECT().FetchTargets(gomock.Any()).Return(target.Targets{
+		1: &target.Target{Number: 1},
+		2: &target.Target{Number: 2},
+	}, nil)
+	m.EXPECT().FetchTarget(gomock.Any(), gomock.Eq(2)).Return(nil, errors.New("API rate limit exceeded"))
+
+	if err := r.Run(context.Background()); !errors.As(err, &erro.FailedError{}) {
+		t.Errorf("got %v\nwant %v", err, erro.FailedError{})
+	}
+	if got := len(r.failures); got != 2 {
+		t.Errorf("got %v\nwant %v", got, 2)
+	}
+	for _, n := range []int{1, 2} {
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("after_%d", n))); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k1LoW/ghdag/store"
+)
+
+const (
+	strategyRandom     = "random"
+	strategyWorkload   = "workload"
+	strategyRoundRobin = "round_robin"
+)
+
+// sampleByStrategy samples candidates by the strategy of <envPrefix>_STRATEGY.
+// envPrefix is GITHUB_REVIEWERS, GITHUB_ASSIGNEES, GITHUB_COMMENT_MENTIONS or SLACK_MENTIONS.
+// configured is the list of users in the config before filtering, and author is the author of the target ( or empty ).
+func (r *Runner) sampleByStrategy(ctx context.Context, configured, in []string, author, envPrefix string) ([]string, error) {
+	envKey := fmt.Sprintf("%s_SAMPLE", envPrefix)
+	s := os.Getenv(fmt.Sprintf("%s_STRATEGY", envPrefix))
+	switch s {
+	case strategyRandom, "":
+		return r.sample(in, envKey)
+	case strategyWorkload:
+		var kind string
+		switch envPrefix {
+		case "GITHUB_REVIEWERS":
+			kind = "reviewers"
+		case "GITHUB_ASSIGNEES":
+			kind = "assignees"
+		default:
+			return nil, fmt.Errorf("%s is not supported for %s", s, envPrefix)
+		}
+		return r.sampleByWorkload(ctx, in, kind, envKey)
+	case strategyRoundRobin:
+		return r.sampleByRoundRobin(configured, in, author, envPrefix, envKey)
+	default:
+		return nil, fmt.Errorf("invalid strategy: %s", s)
+	}
+}
+
+// sampleByRoundRobin picks the candidates in rotation. The cursor of each configured list is persisted in the state file,
+// so the rotation continues even if the candidates are filtered ( ex. away, `exclude:` ).
+func (r *Runner) sampleByRoundRobin(configured, in []string, author, envPrefix, envKey string) ([]string, error) {
+	pool := exclude(unique(in), author)
+	sortStringSlice(pool)
+	sn := len(pool)
+	if os.Getenv(envKey) != "" {
+		n, err := strconv.Atoi(os.Getenv(envKey))
+		if err != nil {
+			return nil, err
+		}
+		sn = n
+	}
+	if len(pool) == 0 || sn <= 0 {
+		return []string{}, nil
+	}
+	if err := r.openStore(); err != nil {
+		return nil, err
+	}
+	key := roundRobinKey(envPrefix, configured)
+	start := 0
+	if last, ok := r.store.Get(key); ok {
+		// continue from the next candidate of the last selected user in alphabetical order,
+		// even if the last selected user is not in the candidates this time
+		start = sort.Search(len(pool), func(i int) bool { return pool[i] > last })
+	}
+	selected := []string{}
+	for i := 0; i < len(pool) && len(selected) < sn; i++ {
+		selected = append(selected, pool[(start+i)%len(pool)])
+	}
+	if len(selected) == 0 {
+		return selected, nil
+	}
+	r.debuglog(fmt.Sprintf("Round robin of %s: %v", envPrefix, selected))
+	if err := r.store.Set(key, selected[len(selected)-1]); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+func roundRobinKey(envPrefix string, configured []string) string {
+	c := unique(configured)
+	sortStringSlice(c)
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.Join(c, ",")))
+	return fmt.Sprintf("round_robin.%s.%s.%x", os.Getenv("GITHUB_REPOSITORY"), strings.ToLower(envPrefix), h.Sum64())
+}
+
+func (r *Runner) openStore() error {
+	if r.store != nil {
+		return nil
+	}
+	s, err := store.Open(store.DefaultPath())
+	if err != nil {
+		return err
+	}
+	r.store = s
+	return nil
+}
+
+// sampleByWorkload picks the candidates with the fewest open review requests ( or assignments )
+func (r *Runner) sampleByWorkload(ctx context.Context, in []string, kind, envKey string) ([]string, error) {
+	if r.excludeKey >= 0 {
+		in = unset(in, r.excludeKey)
+	}
+	sn := len(in)
+	if os.Getenv(envKey) != "" {
+		n, err := strconv.Atoi(os.Getenv(envKey))
+		if err != nil {
+			return nil, err
+		}
+		sn = n
+	}
+	workload, err := r.workload(ctx, in, kind)
+	if err != nil {
+		return nil, err
+	}
+	r.debuglog(fmt.Sprintf("Workload of %s: %v", kind, workload))
+
+	// tie-breaking by seed
+	candidates := make([]string, len(in))
+	copy(candidates, in)
+	rand.New(rand.NewSource(r.seed)).Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return workload[candidates[i]] < workload[candidates[j]]
+	})
+	if len(candidates) > sn {
+		candidates = candidates[:sn]
+	}
+	return candidates, nil
+}
+
+// workload returns the number of open review requests ( or assignments ) of each candidate in the repository.
+// It is computed from the fetched targets if all open issues and pull requests have been fetched, otherwise by search queries.
+func (r *Runner) workload(ctx context.Context, in []string, kind string) (map[string]int, error) {
+	w := map[string]int{}
+	for _, u := range in {
+		switch {
+		case r.allTargets != nil:
+			for _, t := range r.allTargets {
+				var assigned []string
+				switch kind {
+				case "reviewers":
+					// only pending review requests, as `review-requested:` of the search query
+					assigned = t.RequestedReviewers
+				case "assignees":
+					assigned = t.Assignees
+				}
+				if contains(assigned, u) {
+					w[u]++
+				}
+			}
+		default:
+			c, err := r.github.SearchCount(ctx, workloadQuery(kind, u))
+			if err != nil {
+				return nil, err
+			}
+			w[u] = c
+		}
+		// assigned during this session
+		w[u] += r.assigned[kind][u]
+	}
+	return w, nil
+}
+
+// addWorkload records the users assigned during this session
+func (r *Runner) addWorkload(kind string, users []string) {
+	if _, ok := r.assigned[kind]; !ok {
+		r.assigned[kind] = map[string]int{}
+	}
+	for _, u := range users {
+		r.assigned[kind][u]++
+	}
+}
+
+func workloadQuery(kind, u string) string {
+	repo := os.Getenv("GITHUB_REPOSITORY")
+	switch {
+	case kind == "reviewers" && strings.Contains(u, "/"):
+		return fmt.Sprintf("repo:%s is:pr is:open team-review-requested:%s", repo, u)
+	case kind == "reviewers":
+		return fmt.Sprintf("repo:%s is:pr is:open review-requested:%s", repo, u)
+	default:
+		return fmt.Sprintf("repo:%s is:open assignee:%s", repo, u)
+	}
+}
@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/ghdag/mock"
+	"github.com/k1LoW/ghdag/target"
+)
+
+func TestSampleByWorkload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	m := mock.NewMockGhClient(ctrl)
+	r.github = m
+	ctx := context.Background()
+
+	// from the fetched targets
+	r.allTargets = target.Targets{
+		1: &target.Target{Number: 1, RequestedReviewers: []string{"alice", "bob"}, Reviewers: []string{"alice", "bob", "dave"}},
+		2: &target.Target{Number: 2, RequestedReviewers: []string{"alice"}},
+		3: &target.Target{Number: 3, RequestedReviewers: []string{"charlie"}, Assignees: []string{"alice"}},
+	}
+	if err := os.Setenv("GITHUB_REVIEWERS_STRATEGY", "workload"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("GITHUB_REVIEWERS_SAMPLE", "2"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.sampleByStrategy(ctx, []string{"alice", "bob", "charlie", "dave"}, []string{"alice", "bob", "charlie", "dave"}, "", "GITHUB_REVIEWERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "dave" {
+		t.Errorf("got %v\nwant [dave, bob or charlie]", got)
+	}
+	if contains(got, "alice") {
+		t.Errorf("got %v", got)
+	}
+
+	// assigned during this session
+	r.addWorkload("reviewers", []string{"dave", "dave"})
+	got, err = r.sampleByStrategy(ctx, []string{"alice", "dave", "bob"}, []string{"alice", "dave", "bob"}, "", "GITHUB_REVIEWERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "bob" {
+		t.Errorf("got %v\nwant [bob, alice or dave]", got)
+	}
+
+	// by search queries
+	r.allTargets = nil
+	r.assigned = map[string]map[string]int{}
+	if err := os.Setenv("GITHUB_REPOSITORY", "k1LoW/ghdag"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("GITHUB_ASSIGNEES_STRATEGY", "workload"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("GITHUB_ASSIGNEES_SAMPLE", "1"); err != nil {
+		t.Fatal(err)
+	}
+	m.EXPECT().SearchCount(gomock.Eq(ctx), gomock.Eq("repo:k1LoW/ghdag is:open assignee:alice")).Return(3, nil)
+	m.EXPECT().SearchCount(gomock.Eq(ctx), gomock.Eq("repo:k1LoW/ghdag is:open assignee:bob")).Return(1, nil)
+	got, err = r.sampleByStrategy(ctx, []string{"alice", "bob"}, []string{"alice", "bob"}, "", "GITHUB_ASSIGNEES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, []string{"bob"}, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	// tie-breaking by seed is deterministic
+	r.allTargets = target.Targets{}
+	r.seed = 1
+	a, err := r.sampleByStrategy(ctx, []string{"alice", "bob", "charlie"}, []string{"alice", "bob", "charlie"}, "", "GITHUB_REVIEWERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := r.sampleByStrategy(ctx, []string{"alice", "bob", "charlie"}, []string{"alice", "bob", "charlie"}, "", "GITHUB_REVIEWERS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(a, b, nil); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestSampleByRoundRobin(t *testing.T) {
+	r, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.revertEnv(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	ctx := context.Background()
+	p := filepath.Join(t.TempDir(), "state.json")
+	for k, v := range map[string]string{
+		"GHDAG_STATE_FILE":          p,
+		"GITHUB_REPOSITORY":         "k1LoW/ghdag",
+		"GITHUB_REVIEWERS_STRATEGY": "round_robin",
+		"GITHUB_REVIEWERS_SAMPLE":   "1",
+		"SLACK_MENTIONS_STRATEGY":   "round_robin",
+		"SLACK_MENTIONS_SAMPLE":     "2",
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all := []string{"alice", "bob", "charlie"}
+	tests := []struct {
+		configured []string
+		in         []string
+		envPrefix  string
+		author     string
+		want       []string
+	}{
+		{all, []string{"charlie", "alice", "bob"}, "GITHUB_REVIEWERS", "", []string{"alice"}},
+		{all, all, "GITHUB_REVIEWERS", "", []string{"bob"}},
+		{all, all, "GITHUB_REVIEWERS", "charlie", []string{"alice"}},
+		{all, all, "GITHUB_REVIEWERS", "", []string{"bob"}},
+		// bob is away, so the rotation continues from the next of bob
+		{all, []string{"alice", "charlie"}, "GITHUB_REVIEWERS", "", []string{"charlie"}},
+		{all, []string{"alice", "bob"}, "GITHUB_REVIEWERS", "", []string{"alice"}},
+		// another list
+		{[]string{"alice", "bob"}, []string{"alice", "bob"}, "GITHUB_REVIEWERS", "", []string{"alice"}},
+		{all, all, "SLACK_MENTIONS", "", []string{"alice", "bob"}},
+		{all, all, "SLACK_MENTIONS", "alice", []string{"charlie", "bob"}},
+	}
+	for _, tt := range tests {
+		r.store = nil // new session
+		got, err := r.sampleByStrategy(ctx, tt.configured, tt.in, tt.author, tt.envPrefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package slk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k1LoW/duration"
+	"github.com/slack-go/slack"
+)
+
+const (
+	kindUser      = "user"
+	kindEmail     = "email"
+	kindUserGroup = "usergroup"
+	kindChannel   = "channel"
+)
+
+const defaultCacheTTL = 24 * time.Hour
+
+var (
+	userIDRe      = regexp.MustCompile(`^[UW][A-Z0-9]{8,}$`)
+	userGroupIDRe = regexp.MustCompile(`^S[A-Z0-9]{8,}$`)
+	channelIDRe   = regexp.MustCompile(`^[CG][A-Z0-9]{8,}$`)
+)
+
+// cacheTTL returns the TTL of the on-disk cache of Slack users, user groups and channels.
+// If SLACK_CACHE_TTL is 0, the on-disk cache is disabled.
+func cacheTTL() (time.Duration, error) {
+	v := os.Getenv("SLACK_CACHE_TTL")
+	if v == "" {
+		return defaultCacheTTL, nil
+	}
+	if v == "0" {
+		return 0, nil
+	}
+	return duration.Parse(v)
+}
+
+func cacheKey(kind, name string) string {
+	return fmt.Sprintf("%s%s", cacheKeyPrefix(kind), name)
+}
+
+func cacheKeyPrefix(kind string) string {
+	return fmt.Sprintf("slack.cache.%s.", kind)
+}
+
+func cacheUpdatedAtKey(kind string) string {
+	return fmt.Sprintf("slack.cache.updated_at.%s", kind)
+}
+
+// cacheEntryUpdatedAtKey returns the key of the update time of the entry.
+// Emails are looked up one by one ( users.lookupByEmail ), so the TTL is applied per entry.
+func cacheEntryUpdatedAtKey(kind, name string) string {
+	return fmt.Sprintf("slack.cache.updated_at.%s.%s", kind, name)
+}
+
+// getCachedID returns the ID of the name from the in-memory cache or the on-disk cache
+func (c *Client) getCachedID(kind, name string) (string, bool, error) {
+	if id, ok := c.cache[kind][name]; ok {
+		return id, true, nil
+	}
+	ttl, err := cacheTTL()
+	if err != nil {
+		return "", false, err
+	}
+	if ttl == 0 {
+		return "", false, nil
+	}
+	if err := c.openStore(); err != nil {
+		return "", false, err
+	}
+	if kind == kindEmail {
+		u, ok := c.store.Get(cacheEntryUpdatedAtKey(kind, name))
+		if !ok {
+			return "", false, nil
+		}
+		updatedAt, err := strconv.ParseInt(u, 10, 64)
+		if err != nil || time.Since(time.Unix(updatedAt, 0)) > ttl {
+			// prune the stale entry ( ex. the address has been reassigned or deactivated )
+			if err := c.store.Delete(cacheKey(kind, name)); err != nil {
+				return "", false, err
+			}
+			if err := c.store.Delete(cacheEntryUpdatedAtKey(kind, name)); err != nil {
+				return "", false, err
+			}
+			return "", false, nil
+		}
+	} else {
+		u, ok := c.store.Get(cacheUpdatedAtKey(kind))
+		if !ok {
+			return "", false, nil
+		}
+		updatedAt, err := strconv.ParseInt(u, 10, 64)
+		if err != nil || time.Since(time.Unix(updatedAt, 0)) > ttl {
+			return "", false, nil
+		}
+	}
+	id, ok := c.store.Get(cacheKey(kind, name))
+	if !ok {
+		return "", false, nil
+	}
+	c.cache[kind][name] = id
+	return id, true, nil
+}
+
+// setCachedIDs sets the IDs to the in-memory cache and the on-disk cache.
+// Users, user groups and channels are fetched in bulk, so the IDs replace all the cached IDs of the kind.
+func (c *Client) setCachedIDs(kind string, ids map[string]string) error {
+	if kind != kindEmail {
+		c.cache[kind] = map[string]string{}
+	}
+	values := map[string]string{}
+	for name, id := range ids {
+		c.cache[kind][name] = id
+		values[cacheKey(kind, name)] = id
+	}
+	ttl, err := cacheTTL()
+	if err != nil {
+		return err
+	}
+	if ttl == 0 {
+		return nil
+	}
+	if err := c.openStore(); err != nil {
+		return err
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if kind == kindEmail {
+		for name := range ids {
+			values[cacheEntryUpdatedAtKey(kind, name)] = now
+		}
+	} else {
+		// prune the IDs of deleted or renamed users, user groups and channels
+		if err := c.store.DeletePrefix(cacheKeyPrefix(kind)); err != nil {
+			return err
+		}
+		values[cacheUpdatedAtKey(kind)] = now
+	}
+	return c.store.SetAll(values)
+}
+
+// getUserID returns the user ID of the name, the email or the raw user ID
+func (c *Client) getUserID(ctx context.Context, name string) (string, bool, error) {
+	name = strings.TrimPrefix(name, "@")
+	if userIDRe.MatchString(name) {
+		return name, true, nil
+	}
+	if strings.Contains(name, "@") {
+		return c.getUserIDByEmail(ctx, name)
+	}
+	id, ok, err := c.getCachedID(kindUser, name)
+	if err != nil || ok {
+		return id, ok, err
+	}
+	if c.fetched[kindUser] {
+		return "", false, nil
+	}
+	users, err := c.client.GetUsersContext(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	c.fetched[kindUser] = true
+	ids := map[string]string{}
+	for _, u := range users {
+		ids[u.Name] = u.ID
+	}
+	if err := c.setCachedIDs(kindUser, ids); err != nil {
+		return "", false, err
+	}
+	id, ok = ids[name]
+	return id, ok, nil
+}
+
+func (c *Client) getUserIDByEmail(ctx context.Context, email string) (string, bool, error) {
+	id, ok, err := c.getCachedID(kindEmail, email)
+	if err != nil || ok {
+		return id, ok, err
+	}
+	u, err := c.client.GetUserByEmailContext(ctx, email)
+	if err != nil {
+		if err.Error() == "users_not_found" {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if err := c.setCachedIDs(kindEmail, map[string]string{email: u.ID}); err != nil {
+		return "", false, err
+	}
+	return u.ID, true, nil
+}
+
+// getUserGroupID returns the user group ID of the handle or the raw user group ID
+func (c *Client) getUserGroupID(ctx context.Context, handle string) (string, bool, error) {
+	handle = strings.TrimPrefix(handle, "@")
+	if userGroupIDRe.MatchString(handle) {
+		return handle, true, nil
+	}
+	id, ok, err := c.getCachedID(kindUserGroup, handle)
+	if err != nil || ok {
+		return id, ok, err
+	}
+	if c.fetched[kindUserGroup] {
+		return "", false, nil
+	}
+	groups, err := c.client.GetUserGroupsContext(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	c.fetched[kindUserGroup] = true
+	ids := map[string]string{}
+	for _, g := range groups {
+		ids[g.Handle] = g.ID
+	}
+	if err := c.setCachedIDs(kindUserGroup, ids); err != nil {
+		return "", false, err
+	}
+	id, ok = ids[handle]
+	return id, ok, nil
+}
+
+// getChannelID returns the channel ID of the name or the raw channel ID
+func (c *Client) getChannelID(ctx context.Context, channel string) (string, error) {
+	channel = strings.TrimPrefix(channel, "#")
+	if channelIDRe.MatchString(channel) {
+		return channel, nil
+	}
+	id, ok, err := c.getCachedID(kindChannel, channel)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return id, nil
+	}
+	if !c.fetched[kindChannel] {
+		ids := map[string]string{}
+		nc := ""
+		for {
+			p := &slack.GetConversationsParameters{
+				ExcludeArchived: "true",
+				Limit:           1000,
+				Cursor:          nc,
+			}
+			var ch []slack.Channel
+			ch, nc, err = c.client.GetConversationsContext(ctx, p)
+			if err != nil {
+				return "", err
+			}
+			for _, cc := range ch {
+				ids[cc.Name] = cc.ID
+			}
+			if nc == "" {
+				break
+			}
+		}
+		c.fetched[kindChannel] = true
+		if err := c.setCachedIDs(kindChannel, ids); err != nil {
+			return "", err
+		}
+		if id, ok := ids[channel]; ok {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("not found channel: %s", channel)
+}
@@ -0,0 +1,288 @@
+package slk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/ghdag/env"
+	"github.com/k1LoW/ghdag/store"
+	"github.com/slack-go/slack"
+)
+
+func TestCachedIDs(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	p := filepath.Join(t.TempDir(), "state.json")
+	os.Setenv("GHDAG_STATE_FILE", p)
+
+	tests := []struct {
+		ttl       string
+		updatedAt time.Time
+		want      bool
+	}{
+		{"", time.Now(), true},
+		{"1 hour", time.Now().Add(-2 * time.Hour), false},
+		{"3 hours", time.Now().Add(-2 * time.Hour), true},
+		{"0", time.Now(), false},
+	}
+	for _, tt := range tests {
+		os.Setenv("SLACK_CACHE_TTL", tt.ttl)
+		c, err := NewClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.setCachedIDs(kindUser, map[string]string{"alice": "UALICE000"}); err != nil {
+			t.Fatal(err)
+		}
+		if c.store != nil {
+			if err := c.store.Set(cacheUpdatedAtKey(kindUser), strconv.FormatInt(tt.updatedAt.Unix(), 10)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// new process
+		c2, err := NewClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok, err := c2.getCachedID(kindUser, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("got %v\nwant %v", ok, tt.want)
+		}
+		if ok && got != "UALICE000" {
+			t.Errorf("got %v\nwant %v", got, "UALICE000")
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRawIDs(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := c.getUserID(ctx, "U012AB3CDE"); err != nil || !ok || got != "U012AB3CDE" {
+		t.Errorf("got %v %v %v", got, ok, err)
+	}
+	if got, ok, err := c.getUserGroupID(ctx, "@S0614TZR7A"); err != nil || !ok || got != "S0614TZR7A" {
+		t.Errorf("got %v %v %v", got, ok, err)
+	}
+	if got, err := c.getChannelID(ctx, "#C1234567890"); err != nil || got != "C1234567890" {
+		t.Errorf("got %v %v", got, err)
+	}
+}
+
+func TestGetMentionLinkByID(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"here", "<!here>"},
+		{"U012AB3CDE", "<@U012AB3CDE>"},
+		{"@S0614TZR7A", "<!subteam^S0614TZR7A>"},
+	}
+	for _, tt := range tests {
+		if !IsMentionID(tt.in) {
+			t.Errorf("%s should be a mention ID", tt.in)
+		}
+		got, err := c.GetMentionLinkByName(ctx, tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+	if IsMentionID("alice") {
+		t.Error("alice should not be a mention ID")
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	p := filepath.Join(t.TempDir(), "state.json")
+	if err := os.Setenv("GHDAG_STATE_FILE", p); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("SLACK_CACHE_TTL", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// the store opened by the runner ( ex. round_robin strategy )
+	s, err := store.Open(store.DefaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetStore(s)
+
+	if err := c.setCachedIDs(kindUser, map[string]string{"alice": "UALICE000"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("round_robin.k1LoW/ghdag.github_reviewers.0", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.setCachedIDs(kindChannel, map[string]string{"general": "C1234567890"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// new process
+	s2, err := store.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{cacheKey(kindUser, "alice"), cacheKey(kindChannel, "general"), "round_robin.k1LoW/ghdag.github_reviewers.0"} {
+		if _, ok := s2.Get(k); !ok {
+			t.Errorf("not found %s", k)
+		}
+	}
+}
+
+func TestCachedEmailIDs(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	p := filepath.Join(t.TempDir(), "state.json")
+	if err := os.Setenv("GHDAG_STATE_FILE", p); err != nil {
+		t.Fatal(err)
+	}
+
+	email := "alice@example.com"
+	tests := []struct {
+		ttl       string
+		updatedAt time.Time
+		want      bool
+	}{
+		{"", time.Now(), true},
+		{"", time.Now().Add(-25 * time.Hour), false},
+		{"1 hour", time.Now().Add(-2 * time.Hour), false},
+		{"3 hours", time.Now().Add(-2 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		if err := os.Setenv("SLACK_CACHE_TTL", tt.ttl); err != nil {
+			t.Fatal(err)
+		}
+		c, err := NewClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.setCachedIDs(kindEmail, map[string]string{email: "UALICE000"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.store.Set(cacheEntryUpdatedAtKey(kindEmail, email), strconv.FormatInt(tt.updatedAt.Unix(), 10)); err != nil {
+			t.Fatal(err)
+		}
+
+		// new process
+		c2, err := NewClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok, err := c2.getCachedID(kindEmail, email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("got %v\nwant %v", ok, tt.want)
+		}
+		if ok && got != "UALICE000" {
+			t.Errorf("got %v\nwant %v", got, "UALICE000")
+		}
+		if !ok {
+			// the stale entry is pruned
+			if _, exist := c2.store.Get(cacheKey(kindEmail, email)); exist {
+				t.Errorf("stale entry is not pruned: %s", email)
+			}
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRefetchIDs(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := os.Setenv("GHDAG_STATE_FILE", filepath.Join(t.TempDir(), "state.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"ok":true,"members":[{"id":"UBOB00000","name":"bob"}],"response_metadata":{"next_cursor":""}}`)
+	}))
+	defer ts.Close()
+
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.setCachedIDs(kindUser, map[string]string{"alice": "UALICE000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// new process
+	c2, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2.client = slack.New("xoxb-test", slack.OptionAPIURL(ts.URL+"/"))
+	ctx := context.Background()
+
+	// bob has joined since the last fetch
+	if got, ok, err := c2.getUserID(ctx, "bob"); err != nil || !ok || got != "UBOB00000" {
+		t.Errorf("got %v %v %v\nwant %v", got, ok, err, "UBOB00000")
+	}
+	if _, ok, err := c2.getUserID(ctx, "carol"); err != nil || ok {
+		t.Errorf("got %v %v\nwant %v", ok, err, false)
+	}
+	if fetched != 1 {
+		t.Errorf("got %v\nwant %v", fetched, 1)
+	}
+	// alice has been deleted, so her cached ID is pruned
+	if _, ok, err := c2.getUserID(ctx, "alice"); err != nil || ok {
+		t.Errorf("got %v %v\nwant %v", ok, err, false)
+	}
+	if got := c2.store.Keys(cacheKeyPrefix(kindUser)); len(got) != 1 || got[0] != cacheKey(kindUser, "bob") {
+		t.Errorf("got %v\nwant %v", got, []string{cacheKey(kindUser, "bob")})
+	}
+}
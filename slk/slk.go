@@ -2,31 +2,47 @@ package slk
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/k1LoW/ghdag/env"
+	"github.com/k1LoW/ghdag/store"
 	"github.com/slack-go/slack"
 )
 
 type SlkClient interface {
-	PostMessage(ctx context.Context, m string) error
+	PostMessage(ctx context.Context, m string, blocks ...slack.Block) (*Message, error)
+	DeleteMessages(ctx context.Context, n int) error
+	ForgetMessages(n int) error
+	PostDirectMessage(ctx context.Context, name, m string, blocks ...slack.Block) error
 	GetMentionLinkByName(ctx context.Context, name string) (string, error)
 }
 
+// Message is a sent Slack message
+type Message struct {
+	Channel   string
+	Timestamp string
+}
+
 type Client struct {
-	client         *slack.Client
-	channelCache   map[string]slack.Channel
-	userCache      map[string]slack.User
-	userGroupCache map[string]slack.UserGroup
+	client  *slack.Client
+	cache   map[string]map[string]string
+	fetched map[string]bool
+	store   *store.Store
 }
 
 func NewClient() (*Client, error) {
 	c := &Client{
-		channelCache:   map[string]slack.Channel{},
-		userCache:      map[string]slack.User{},
-		userGroupCache: map[string]slack.UserGroup{},
+		cache: map[string]map[string]string{
+			kindUser:      {},
+			kindEmail:     {},
+			kindUserGroup: {},
+			kindChannel:   {},
+		},
+		fetched: map[string]bool{},
 	}
 	if os.Getenv("SLACK_API_TOKEN") != "" {
 		c.client = slack.New(os.Getenv("SLACK_API_TOKEN"))
@@ -34,98 +50,213 @@ func NewClient() (*Client, error) {
 	return c, nil
 }
 
-func (c *Client) PostMessage(ctx context.Context, m string) error {
+func (c *Client) PostMessage(ctx context.Context, m string, blocks ...slack.Block) (*Message, error) {
 	switch {
 	case c.client != nil:
-		return c.postMessage(ctx, m)
+		return c.postMessage(ctx, m, blocks)
 	case os.Getenv("SLACK_API_TOKEN") != "":
 		// temporary
 		c.client = slack.New(os.Getenv("SLACK_API_TOKEN"))
-		err := c.postMessage(ctx, m)
+		msg, err := c.postMessage(ctx, m, blocks)
 		c.client = nil
-		return err
+		return msg, err
 	case os.Getenv("SLACK_WEBHOOK_URL") != "":
-		return c.postWebbookMessage(ctx, m)
+		if os.Getenv("SLACK_MESSAGE_KEY") != "" {
+			return nil, errors.New("notification using webhook does not support updating messages")
+		}
+		return nil, c.postWebbookMessage(ctx, m, blocks)
 	default:
-		return errors.New("not found environment for Slack: SLACK_API_TOKEN or SLACK_WEBHOOK_URL")
+		return nil, errors.New("not found environment for Slack: SLACK_API_TOKEN or SLACK_WEBHOOK_URL")
 	}
-	return nil
 }
 
-func (c *Client) postMessage(ctx context.Context, m string) error {
+func (c *Client) postMessage(ctx context.Context, m string, blocks []slack.Block) (*Message, error) {
 	if os.Getenv("SLACK_CHANNEL") == "" {
-		return errors.New("not found environment for Slack: SLACK_CHANNEL")
+		return nil, errors.New("not found environment for Slack: SLACK_CHANNEL")
 	}
 	channel := os.Getenv("SLACK_CHANNEL")
-	channelID, err := c.getChannelIDByName(ctx, channel)
+	channelID, err := c.getChannelID(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	opts := buildMsgOptions(m, blocks)
+
+	messageKey := ""
+	if key := os.Getenv("SLACK_MESSAGE_KEY"); key != "" && os.Getenv("GHDAG_TARGET_NUMBER") != "" {
+		if err := c.openStore(); err != nil {
+			return nil, err
+		}
+		messageKey = fmt.Sprintf("%s%s", messageKeyPrefix(os.Getenv("GHDAG_TARGET_NUMBER")), key)
+		if v, ok := c.store.Get(messageKey); ok {
+			prev := parseMessage(v)
+			_, ts, _, err := c.client.UpdateMessageContext(ctx, prev.Channel, prev.Timestamp, opts...)
+			if err == nil {
+				return &Message{Channel: prev.Channel, Timestamp: ts}, nil
+			}
+			if err.Error() != "message_not_found" {
+				return nil, err
+			}
+			// the previous message has been deleted, so post a new one
+		}
+	}
+
+	threadKey := ""
+	if env.GetenvAsBool("SLACK_THREAD") && os.Getenv("GHDAG_TARGET_NUMBER") != "" {
+		if err := c.openStore(); err != nil {
+			return nil, err
+		}
+		threadKey = fmt.Sprintf("%s%s", threadKeyPrefix(os.Getenv("GHDAG_TARGET_NUMBER")), channelID)
+		if ts, ok := c.store.Get(threadKey); ok {
+			threadKey = ""
+			opts = append(opts, slack.MsgOptionTS(ts))
+			if env.GetenvAsBool("SLACK_THREAD_BROADCAST") {
+				opts = append(opts, slack.MsgOptionBroadcast())
+			}
+		}
+	}
+
+	_, ts, err := c.client.PostMessageContext(ctx, channelID, opts...)
 	if err != nil {
+		return nil, err
+	}
+	msg := &Message{Channel: channelID, Timestamp: ts}
+	if threadKey != "" {
+		// first message for the target becomes the parent of the thread
+		if err := c.store.Set(threadKey, ts); err != nil {
+			return nil, err
+		}
+	}
+	if messageKey != "" {
+		if err := c.store.Set(messageKey, msg.String()); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// DeleteMessages deletes the messages sent with SLACK_MESSAGE_KEY for the target
+func (c *Client) DeleteMessages(ctx context.Context, n int) error {
+	if c.client == nil {
+		if os.Getenv("SLACK_API_TOKEN") == "" {
+			return errors.New("not found environment for Slack: SLACK_API_TOKEN")
+		}
+		// temporary
+		c.client = slack.New(os.Getenv("SLACK_API_TOKEN"))
+		defer func() {
+			c.client = nil
+		}()
+	}
+	if err := c.openStore(); err != nil {
 		return err
 	}
-	opts := []slack.MsgOption{
-		slack.MsgOptionBlocks(buildBlocks(m)...),
+	for _, k := range c.store.Keys(messageKeyPrefix(fmt.Sprintf("%d", n))) {
+		v, _ := c.store.Get(k)
+		msg := parseMessage(v)
+		if _, _, err := c.client.DeleteMessageContext(ctx, msg.Channel, msg.Timestamp); err != nil && err.Error() != "message_not_found" {
+			return err
+		}
+		if err := c.store.Delete(k); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	if username := os.Getenv("SLACK_USERNAME"); username != "" {
-		opts = append(opts, slack.MsgOptionUsername(username))
+// ForgetMessages deletes the messages and the threads of the target from the state file ( not from Slack ),
+// because they are no longer updated after the target is closed
+func (c *Client) ForgetMessages(n int) error {
+	if err := c.openStore(); err != nil {
+		return err
+	}
+	if err := c.store.DeletePrefix(messageKeyPrefix(fmt.Sprintf("%d", n))); err != nil {
+		return err
 	}
+	return c.store.DeletePrefix(threadKeyPrefix(fmt.Sprintf("%d", n)))
+}
 
-	if emoji := os.Getenv("SLACK_ICON_EMOJI"); emoji != "" {
-		opts = append(opts, slack.MsgOptionIconEmoji(emoji))
+func (m *Message) String() string {
+	return fmt.Sprintf("%s %s", m.Channel, m.Timestamp)
+}
+
+func parseMessage(v string) *Message {
+	splitted := strings.SplitN(v, " ", 2)
+	if len(splitted) != 2 {
+		return &Message{}
 	}
+	return &Message{Channel: splitted[0], Timestamp: splitted[1]}
+}
 
-	if url := os.Getenv("SLACK_ICON_URL"); url != "" {
-		opts = append(opts, slack.MsgOptionIconURL(url))
+func messageKeyPrefix(n string) string {
+	return fmt.Sprintf("slack.message.%s#%s.", os.Getenv("GITHUB_REPOSITORY"), n)
+}
+
+func threadKeyPrefix(n string) string {
+	return fmt.Sprintf("slack.thread.%s#%s.", os.Getenv("GITHUB_REPOSITORY"), n)
+}
+
+func (c *Client) PostDirectMessage(ctx context.Context, name, m string, blocks ...slack.Block) error {
+	if c.client == nil {
+		if os.Getenv("SLACK_API_TOKEN") == "" {
+			return errors.New("not found environment for Slack direct message: SLACK_API_TOKEN")
+		}
+		// temporary
+		c.client = slack.New(os.Getenv("SLACK_API_TOKEN"))
+		defer func() {
+			c.client = nil
+		}()
 	}
+	id, ok, err := c.getUserID(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("not found user: %s", name)
+	}
+	ch, _, _, err := c.client.OpenConversationContext(ctx, &slack.OpenConversationParameters{
+		Users: []string{id},
+	})
+	if err != nil {
+		return err
+	}
+	if _, _, err := c.client.PostMessageContext(ctx, ch.ID, buildMsgOptions(m, blocks)...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetStore sets the store shared in the process. The store must be shared with the other clients
+// that persist state to the same state file, otherwise they overwrite the values of each other.
+func (c *Client) SetStore(s *store.Store) {
+	c.store = s
+}
 
-	if _, _, err := c.client.PostMessageContext(ctx, channelID, opts...); err != nil {
+func (c *Client) openStore() error {
+	if c.store != nil {
+		return nil
+	}
+	s, err := store.Open(store.DefaultPath())
+	if err != nil {
 		return err
 	}
+	c.store = s
 	return nil
 }
 
-func (c *Client) postWebbookMessage(ctx context.Context, m string) error {
+func (c *Client) postWebbookMessage(ctx context.Context, m string, blocks []slack.Block) error {
 	url := os.Getenv("SLACK_WEBHOOK_URL")
-	msg := buildWebhookMessage(m)
+	msg := buildWebhookMessage(m, blocks)
 	return slack.PostWebhookContext(ctx, url, msg)
 }
 
-func (c *Client) getChannelIDByName(ctx context.Context, channel string) (string, error) {
-	channel = strings.TrimPrefix(channel, "#")
-	if cc, ok := c.channelCache[channel]; ok {
-		return cc.ID, nil
-	}
-	var (
-		nc  string
-		err error
-		cID string
-	)
-L:
-	for {
-		var ch []slack.Channel
-		p := &slack.GetConversationsParameters{
-			Limit:  1000,
-			Cursor: nc,
-		}
-		ch, nc, err = c.client.GetConversationsContext(ctx, p)
-		if err != nil {
-			return "", err
-		}
-		for _, cc := range ch {
-			c.channelCache[channel] = cc
-			if cc.Name == channel {
-				cID = cc.ID
-				break L
-			}
-		}
-		if nc == "" {
-			break
-		}
-	}
-	if cID == "" {
-		return "", fmt.Errorf("not found channel: %s", channel)
+// IsMentionID returns whether the mention can be rendered without calling Slack API
+// ( special mentions, user IDs and user group IDs )
+func IsMentionID(name string) bool {
+	name = strings.TrimPrefix(name, "@")
+	switch name {
+	case "channel", "here", "everyone":
+		return true
 	}
-
-	return cID, nil
+	return userIDRe.MatchString(name) || userGroupIDRe.MatchString(name)
 }
 
 func (c *Client) GetMentionLinkByName(ctx context.Context, name string) (string, error) {
@@ -136,48 +267,67 @@ func (c *Client) GetMentionLinkByName(ctx context.Context, name string) (string,
 		}()
 	}
 	name = strings.TrimPrefix(name, "@")
-	switch name {
-	case "channel", "here", "everyone":
+	switch {
+	case name == "channel" || name == "here" || name == "everyone":
 		return fmt.Sprintf("<!%s>", name), nil
+	case userIDRe.MatchString(name):
+		return fmt.Sprintf("<@%s>", name), nil
+	case userGroupIDRe.MatchString(name):
+		return fmt.Sprintf("<!subteam^%s>", name), nil
 	}
-	if uc, ok := c.userCache[name]; ok {
-		// https://api.slack.com/reference/surfaces/formatting#mentioning-users
-		return fmt.Sprintf("<@%s>", uc.ID), nil
-	}
-	if gc, ok := c.userGroupCache[name]; ok {
-		// https://api.slack.com/reference/surfaces/formatting#mentioning-groups
-		return fmt.Sprintf("<!subteam^%s>", gc.ID), nil
-	}
-
-	users, err := c.client.GetUsersContext(ctx)
+	uID, ok, err := c.getUserID(ctx, name)
 	if err != nil {
 		return "", err
 	}
-
-	for _, u := range users {
-		c.userCache[u.Name] = u
-	}
-	uc, ok := c.userCache[name]
 	if ok {
-		return fmt.Sprintf("<@%s>", uc.ID), nil
+		// https://api.slack.com/reference/surfaces/formatting#mentioning-users
+		return fmt.Sprintf("<@%s>", uID), nil
 	}
-
-	groups, err := c.client.GetUserGroupsContext(ctx)
+	gID, ok, err := c.getUserGroupID(ctx, name)
 	if err != nil {
 		return "", err
 	}
-	for _, g := range groups {
-		c.userGroupCache[g.Handle] = g
-	}
-	gc, ok := c.userGroupCache[name]
 	if ok {
-		return fmt.Sprintf("<!subteam^%s>", gc.ID), nil
+		// https://api.slack.com/reference/surfaces/formatting#mentioning-groups
+		return fmt.Sprintf("<!subteam^%s>", gID), nil
 	}
 
 	return fmt.Sprintf("<@%s|not found user or usergroup>", name), nil
 }
 
-func buildWebhookMessage(m string) *slack.WebhookMessage {
+func buildMsgOptions(m string, blocks []slack.Block) []slack.MsgOption {
+	opts := []slack.MsgOption{}
+	if len(blocks) > 0 {
+		// text is used as a fallback for notifications
+		opts = append(opts, slack.MsgOptionText(m, false), slack.MsgOptionBlocks(blocks...))
+	} else {
+		opts = append(opts, slack.MsgOptionBlocks(buildBlocks(m)...))
+	}
+
+	if username := os.Getenv("SLACK_USERNAME"); username != "" {
+		opts = append(opts, slack.MsgOptionUsername(username))
+	}
+
+	if emoji := os.Getenv("SLACK_ICON_EMOJI"); emoji != "" {
+		opts = append(opts, slack.MsgOptionIconEmoji(emoji))
+	}
+
+	if url := os.Getenv("SLACK_ICON_URL"); url != "" {
+		opts = append(opts, slack.MsgOptionIconURL(url))
+	}
+	return opts
+}
+
+func buildWebhookMessage(m string, blocks []slack.Block) *slack.WebhookMessage {
+	if len(blocks) > 0 {
+		return &slack.WebhookMessage{
+			Channel: os.Getenv("SLACK_CHANNEL"),
+			Text:    m,
+			Blocks: &slack.Blocks{
+				BlockSet: blocks,
+			},
+		}
+	}
 	return &slack.WebhookMessage{
 		Channel: os.Getenv("SLACK_CHANNEL"),
 		Blocks: &slack.Blocks{
@@ -186,12 +336,32 @@ func buildWebhookMessage(m string) *slack.WebhookMessage {
 	}
 }
 
+// BuildBlocks builds Block Kit blocks from the layout in JSON and appends the default footer
+func BuildBlocks(layout []byte, footer bool) ([]slack.Block, error) {
+	blocks := slack.Blocks{}
+	if err := json.Unmarshal(layout, &blocks); err != nil {
+		return nil, err
+	}
+	if footer {
+		blocks.BlockSet = append(blocks.BlockSet, buildFooterBlock())
+	}
+	return blocks.BlockSet, nil
+}
+
 // buildBlocks
 func buildBlocks(m string) []slack.Block {
-	elements := []slack.MixedElement{slack.NewTextBlockObject("mrkdwn", fmt.Sprintf("%s | <%s|#%s> | %s", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GHDAG_TARGET_URL"), os.Getenv("GHDAG_TARGET_NUMBER"), os.Getenv("GHDAG_TASK_ID")), false, false)}
-	contextBlock := slack.NewContextBlock("footer", elements...)
 	return []slack.Block{
 		slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", m, false, false), nil, nil),
-		contextBlock,
+		buildFooterBlock(),
+	}
+}
+
+func buildFooterBlock() slack.Block {
+	f := fmt.Sprintf("%s | <%s|#%s> | %s", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GHDAG_TARGET_URL"), os.Getenv("GHDAG_TARGET_NUMBER"), os.Getenv("GHDAG_TASK_ID"))
+	if os.Getenv("GHDAG_TARGET_NUMBER") == "" {
+		// e.g. digest
+		f = fmt.Sprintf("%s | %s", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GHDAG_TASK_ID"))
 	}
+	elements := []slack.MixedElement{slack.NewTextBlockObject("mrkdwn", f, false, false)}
+	return slack.NewContextBlock("footer", elements...)
 }
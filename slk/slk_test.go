@@ -0,0 +1,299 @@
+package slk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k1LoW/ghdag/env"
+	"github.com/slack-go/slack"
+)
+
+func TestBuildBlocks(t *testing.T) {
+	tests := []struct {
+		in        string
+		footer    bool
+		wantTypes []slack.MessageBlockType
+		wantErr   bool
+	}{
+		{
+			`[{"type":"section","text":{"type":"mrkdwn","text":"hello"}},{"type":"divider"}]`,
+			true,
+			[]slack.MessageBlockType{slack.MBTSection, slack.MBTDivider, slack.MBTContext},
+			false,
+		},
+		{
+			`[{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"Open"},"url":"https://github.com/k1LoW/ghdag/pull/1"}]}]`,
+			false,
+			[]slack.MessageBlockType{slack.MBTAction},
+			false,
+		},
+		{
+			`{"type":"section"}`,
+			true,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := BuildBlocks([]byte(tt.in), tt.footer)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error")
+			continue
+		}
+		if len(got) != len(tt.wantTypes) {
+			t.Fatalf("got %v\nwant %v", len(got), len(tt.wantTypes))
+		}
+		for i, b := range got {
+			if b.BlockType() != tt.wantTypes[i] {
+				t.Errorf("got %v\nwant %v", b.BlockType(), tt.wantTypes[i])
+			}
+		}
+	}
+}
+
+func TestPostMessageInThread(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for k, v := range map[string]string{
+		"GHDAG_STATE_FILE":    filepath.Join(t.TempDir(), "state.json"),
+		"SLACK_CHANNEL":       "#C1234567890",
+		"SLACK_THREAD":        "true",
+		"GITHUB_REPOSITORY":   "k1LoW/ghdag",
+		"GHDAG_TARGET_NUMBER": "1",
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type posted struct {
+		threadTS  string
+		broadcast string
+	}
+	got := []posted{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got = append(got, posted{threadTS: r.FormValue("thread_ts"), broadcast: r.FormValue("reply_broadcast")})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"ok":true,"channel":"C1234567890","ts":"1000.%04d"}`, len(got))
+	}))
+	defer ts.Close()
+
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.client = slack.New("xoxb-test", slack.OptionAPIURL(ts.URL+"/"))
+	ctx := context.Background()
+
+	for _, m := range []string{"first", "second"} {
+		if _, err := c.PostMessage(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Setenv("SLACK_THREAD_BROADCAST", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PostMessage(ctx, "third"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []posted{
+		{threadTS: "", broadcast: ""},
+		{threadTS: "1000.0001", broadcast: ""},
+		{threadTS: "1000.0001", broadcast: "true"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v\nwant %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v\nwant %v", got[i], want[i])
+		}
+	}
+	if v, ok := c.store.Get("slack.thread.k1LoW/ghdag#1.C1234567890"); !ok || v != "1000.0001" {
+		t.Errorf("got %v %v\nwant %v", v, ok, "1000.0001")
+	}
+}
+
+func TestPostMessageWithMessageKey(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for k, v := range map[string]string{
+		"GHDAG_STATE_FILE":    filepath.Join(t.TempDir(), "state.json"),
+		"SLACK_CHANNEL":       "#C1234567890",
+		"SLACK_MESSAGE_KEY":   "status",
+		"GITHUB_REPOSITORY":   "k1LoW/ghdag",
+		"GHDAG_TARGET_NUMBER": "1",
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type called struct {
+		method string
+		ts     string
+	}
+	got := []called{}
+	deleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got = append(got, called{method: r.URL.Path, ts: r.FormValue("ts")})
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/chat.update" && deleted:
+			_, _ = fmt.Fprint(w, `{"ok":false,"error":"message_not_found"}`)
+		case r.URL.Path == "/chat.update":
+			_, _ = fmt.Fprintf(w, `{"ok":true,"channel":"%s","ts":"%s"}`, r.FormValue("channel"), r.FormValue("ts"))
+		default:
+			_, _ = fmt.Fprintf(w, `{"ok":true,"channel":"C1234567890","ts":"1000.%04d"}`, len(got))
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.client = slack.New("xoxb-test", slack.OptionAPIURL(ts.URL+"/"))
+	ctx := context.Background()
+	key := "slack.message.k1LoW/ghdag#1.status"
+
+	// post, and save the channel and the ts
+	if _, err := c.PostMessage(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.store.Get(key); !ok || v != "C1234567890 1000.0001" {
+		t.Errorf("got %v %v\nwant %v", v, ok, "C1234567890 1000.0001")
+	}
+	// update the saved message
+	if _, err := c.PostMessage(ctx, "second"); err != nil {
+		t.Fatal(err)
+	}
+	// the saved message has been deleted, so post a new one
+	deleted = true
+	if _, err := c.PostMessage(ctx, "third"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.store.Get(key); !ok || v != "C1234567890 1000.0004" {
+		t.Errorf("got %v %v\nwant %v", v, ok, "C1234567890 1000.0004")
+	}
+
+	want := []called{
+		{method: "/chat.postMessage", ts: ""},
+		{method: "/chat.update", ts: "1000.0001"},
+		{method: "/chat.update", ts: "1000.0001"},
+		{method: "/chat.postMessage", ts: ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v\nwant %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v\nwant %v", got[i], want[i])
+		}
+	}
+}
+
+func TestDeleteMessages(t *testing.T) {
+	envCache := os.Environ()
+	defer func() {
+		if err := env.Revert(envCache); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for k, v := range map[string]string{
+		"GHDAG_STATE_FILE":  filepath.Join(t.TempDir(), "state.json"),
+		"GITHUB_REPOSITORY": "k1LoW/ghdag",
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got = append(got, fmt.Sprintf("%s %s %s", r.URL.Path, r.FormValue("channel"), r.FormValue("ts")))
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("ts") == "1000.0002" {
+			// already deleted
+			_, _ = fmt.Fprint(w, `{"ok":false,"error":"message_not_found"}`)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"ok":true,"channel":"%s","ts":"%s"}`, r.FormValue("channel"), r.FormValue("ts"))
+	}))
+	defer ts.Close()
+
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.client = slack.New("xoxb-test", slack.OptionAPIURL(ts.URL+"/"))
+	if err := c.openStore(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.store.SetAll(map[string]string{
+		"slack.message.k1LoW/ghdag#1.build":  "C1234567890 1000.0001",
+		"slack.message.k1LoW/ghdag#1.review": "C1234567890 1000.0002",
+		"slack.message.k1LoW/ghdag#10.build": "C1234567890 1000.0003",
+		"slack.thread.k1LoW/ghdag#1.C123456": "1000.0001",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.DeleteMessages(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/chat.delete C1234567890 1000.0001",
+		"/chat.delete C1234567890 1000.0002",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v\nwant %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v\nwant %v", got[i], want[i])
+		}
+	}
+	if keys := c.store.Keys("slack.message.k1LoW/ghdag#1."); len(keys) != 0 {
+		t.Errorf("got %v\nwant empty", keys)
+	}
+
+	if err := c.ForgetMessages(1); err != nil {
+		t.Fatal(err)
+	}
+	if keys := c.store.Keys("slack."); len(keys) != 1 || keys[0] != "slack.message.k1LoW/ghdag#10.build" {
+		t.Errorf("got %v\nwant %v", keys, []string{"slack.message.k1LoW/ghdag#10.build"})
+	}
+}
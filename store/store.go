@@ -0,0 +1,128 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store is a simple key-value store persisted to a JSON file.
+// It is used to carry state (e.g. Slack message timestamps) across runs.
+type Store struct {
+	path   string
+	values map[string]string
+	mu     sync.Mutex
+}
+
+// DefaultPath returns the path of the state file
+func DefaultPath() string {
+	if p := os.Getenv("GHDAG_STATE_FILE"); p != "" {
+		return p
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ghdag", "state.json")
+}
+
+// Open the state file. If the file does not exist, an empty store is returned.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		values: map[string]string{},
+	}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if len(b) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(b, &s.values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Keys returns the sorted keys that have the prefix
+func (s *Store) Keys(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := []string{}
+	for k := range s.values {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Store) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return s.save()
+}
+
+// SetAll sets the values at once
+func (s *Store) SetAll(values map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range values {
+		s.values[k] = v
+	}
+	return s.save()
+}
+
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; !ok {
+		return nil
+	}
+	delete(s.values, key)
+	return s.save()
+}
+
+// DeletePrefix deletes the keys that have the prefix at once
+func (s *Store) DeletePrefix(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := false
+	for k := range s.values {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.values, k)
+			deleted = true
+		}
+	}
+	if !deleted {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *Store) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, b, 0600)
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "state", "state.json")
+	s, err := Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get("key"); ok {
+		t.Error("got value from empty store")
+	}
+	if err := s.Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	s2, err := Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s2.Get("key"); !ok || got != "value" {
+		t.Errorf("got %v\nwant %v", got, "value")
+	}
+	if err := s2.Set("prefix.b", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.Set("prefix.a", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s2.Keys("prefix."); len(got) != 2 || got[0] != "prefix.a" || got[1] != "prefix.b" {
+		t.Errorf("got %v\nwant %v", got, []string{"prefix.a", "prefix.b"})
+	}
+	if err := s2.SetAll(map[string]string{"all.a": "a", "all.b": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s2.Keys("all."); len(got) != 2 {
+		t.Errorf("got %v\nwant %v", len(got), 2)
+	}
+	if err := s2.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.DeletePrefix("all."); err != nil {
+		t.Fatal(err)
+	}
+	if got := s2.Keys("all."); len(got) != 0 {
+		t.Errorf("got %v\nwant %v", len(got), 0)
+	}
+
+	s3, err := Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s3.Get("key"); ok {
+		t.Error("got deleted value")
+	}
+}
@@ -2,56 +2,150 @@ package target
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/goccy/go-json"
 )
 
 // Target is Issue or Pull request
 type Target struct {
-	Number                      int      `json:"number"`
-	State                       string   `json:"state"`
-	Title                       string   `json:"title"`
-	Body                        string   `json:"body"`
-	URL                         string   `json:"url"`
-	Author                      string   `json:"author"`
-	Labels                      []string `json:"labels"`
-	Assignees                   []string `json:"assignees"`
-	Reviewers                   []string `json:"reviewers"`
-	CodeOwners                  []string `json:"code_owners"`
-	ReviewersWhoApproved        []string `json:"reviewers_who_approved"`
-	CodeOwnersWhoApproved       []string `json:"code_owners_who_approved"`
-	IsIssue                     bool     `json:"is_issue"`
-	IsPullRequest               bool     `json:"is_pull_request"`
-	IsApproved                  bool     `json:"is_approved"`
-	IsReviewRequired            bool     `json:"is_review_required"`
-	IsChangeRequested           bool     `json:"is_change_requested"`
-	Mergeable                   bool     `json:"mergeable"`
-	ChangedFiles                int      `json:"changed_files"`
-	HoursElapsedSinceCreated    int      `json:"hours_elapsed_since_created"`
-	HoursElapsedSinceUpdated    int      `json:"hours_elapsed_since_updated"`
-	NumberOfComments            int      `json:"number_of_comments"`
-	LatestCommentAuthor         string   `json:"latest_comment_author"`
-	LatestCommentBody           string   `json:"latest_comment_body"`
-	NumberOfConsecutiveComments int      `json:"-"`
+	Number                      int       `json:"number"`
+	State                       string    `json:"state"`
+	Title                       string    `json:"title"`
+	Body                        string    `json:"body"`
+	URL                         string    `json:"url"`
+	Author                      string    `json:"author"`
+	Labels                      []string  `json:"labels"`
+	Assignees                   []string  `json:"assignees"`
+	Reviewers                   []string  `json:"reviewers"`
+	CodeOwners                  []string  `json:"code_owners"`
+	ReviewersWhoApproved        []string  `json:"reviewers_who_approved"`
+	CodeOwnersWhoApproved       []string  `json:"code_owners_who_approved"`
+	CodeOwnerRulesUnapproved    []string  `json:"code_owner_rules_unapproved"`
+	IsIssue                     bool      `json:"is_issue"`
+	IsPullRequest               bool      `json:"is_pull_request"`
+	IsApproved                  bool      `json:"is_approved"`
+	IsReviewRequired            bool      `json:"is_review_required"`
+	IsChangeRequested           bool      `json:"is_change_requested"`
+	Mergeable                   bool      `json:"mergeable"`
+	MergeStateStatus            string    `json:"merge_state_status"`
+	BehindBase                  bool      `json:"behind_base"`
+	AutoMergeEnabled            bool      `json:"auto_merge_enabled"`
+	ChangedFiles                int       `json:"changed_files"`
+	HoursElapsedSinceCreated    int       `json:"hours_elapsed_since_created"`
+	HoursElapsedSinceUpdated    int       `json:"hours_elapsed_since_updated"`
+	NumberOfComments            int       `json:"number_of_comments"`
+	LatestCommentAuthor         string    `json:"latest_comment_author"`
+	LatestCommentBody           string    `json:"latest_comment_body"`
+	NumberOfConsecutiveComments int       `json:"-"`
+	RequestedReviewers          []string  `json:"-"` // pending review requests ( users and teams )
+	HeadSHA                     string    `json:"-"`
+	BaseRef                     string    `json:"-"`
+	AutoMergeMethod             string    `json:"-"`
+	CreatedAt                   time.Time `json:"-"`
+	UpdatedAt                   time.Time `json:"-"`
+
+	ProjectItems   []*ProjectItem   `json:"project_items"`
+	CodeOwnerRules []*CodeOwnerRule `json:"-"`
 
 	Login string `json:"login"`
 }
 
+// CodeOwnerRule is a rule of the CODEOWNERS file that matches the changed files of the pull request
+type CodeOwnerRule struct {
+	Pattern string
+	Owners  []string
+}
+
+// ProjectItem is an item of GitHub Projects (v2)
+type ProjectItem struct {
+	ID         string          `json:"id"`
+	Number     int             `json:"number"`
+	Title      string          `json:"title"`
+	IsArchived bool            `json:"is_archived"`
+	Fields     []*ProjectField `json:"fields"`
+}
+
+// ProjectField is a field value of the project item
+type ProjectField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Field returns the field value of the project item
+func (i *ProjectItem) Field(name string) (string, bool) {
+	for _, f := range i.Fields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// ProjectItem returns the item of the project that the target belongs to
+func (t *Target) ProjectItem(number int) (*ProjectItem, bool) {
+	for _, i := range t.ProjectItems {
+		if i.Number == number {
+			return i, true
+		}
+	}
+	return nil, false
+}
+
+// NoCodeOwnerReviewers returns the requested reviewers ( users and teams ) except code owners.
+// Reviewers are normalized by NormalizeReviewer.
 func (t *Target) NoCodeOwnerReviewers() []string {
 	nr := []string{}
 	for _, r := range t.Reviewers {
-		if contains(t.CodeOwners, r) {
+		if t.IsCodeOwner(r) {
 			continue
 		}
-		nr = append(nr, r)
+		nr = append(nr, NormalizeReviewer(r))
 	}
 	return nr
 }
 
+// IsCodeOwner returns whether the user or the team ( `org/team` ) is a code owner of the target
+func (t *Target) IsCodeOwner(r string) bool {
+	r = NormalizeReviewer(r)
+	for _, o := range t.CodeOwners {
+		if NormalizeReviewer(o) == r {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeReviewer trims the `@` prefix of the user or the team, and lowercases the team ( `org/team` )
+// because the organization and the slug of the team are case-insensitive.
+func NormalizeReviewer(r string) string {
+	r = strings.TrimPrefix(r, "@")
+	if strings.Contains(r, "/") {
+		return strings.ToLower(r)
+	}
+	return r
+}
+
 func (t *Target) Dump() map[string]interface{} {
 	b, _ := json.Marshal(t)
 	v := map[string]interface{}{}
 	_ = json.Unmarshal(b, &v)
+	// Flatten field values of project items ( ex. `Status` -> `project_status` )
+	for _, i := range t.ProjectItems {
+		if i.IsArchived {
+			continue
+		}
+		for _, f := range i.Fields {
+			k := fmt.Sprintf("project_%s", snakeCase(f.Name))
+			if _, exist := v[k]; exist {
+				continue
+			}
+			v[k] = f.Value
+		}
+	}
 	return v
 }
 
@@ -67,6 +161,45 @@ func (targets Targets) MaxDigits() int {
 	return digits
 }
 
+const (
+	// OrderByNumber sorts targets by number ( default )
+	OrderByNumber = "number"
+	// OrderByCreated sorts targets by the creation time ( the oldest first )
+	OrderByCreated = "created"
+	// OrderByUpdated sorts targets by the last update time ( the least recently updated first )
+	OrderByUpdated = "updated"
+)
+
+// Sorted returns the targets in a stable order. Ties are broken by number.
+func (targets Targets) Sorted(order string) ([]*Target, error) {
+	sorted := []*Target{}
+	for _, t := range targets {
+		sorted = append(sorted, t)
+	}
+	var less func(a, b *Target) bool
+	switch order {
+	case OrderByNumber, "":
+		less = func(a, b *Target) bool { return false }
+	case OrderByCreated:
+		less = func(a, b *Target) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case OrderByUpdated:
+		less = func(a, b *Target) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	default:
+		return nil, fmt.Errorf("invalid order of targets: %s", order)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.Number < b.Number
+	})
+	return sorted, nil
+}
+
 func contains(s []string, e string) bool {
 	for _, v := range s {
 		if e == v {
@@ -75,3 +208,10 @@ func contains(s []string, e string) bool {
 	}
 	return false
 }
+
+func snakeCase(in string) string {
+	s := strings.FieldsFunc(strings.ToLower(in), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(s, "_")
+}
@@ -15,13 +15,72 @@ func (t ActionType) String() string {
 }
 
 type Action struct {
-	Type      ActionType `yaml:"-"`
-	Run       string     `yaml:"run,omitempty"`
-	Labels    []string   `yaml:"labels,omitempty"`
-	Assignees []string   `yaml:"assignees,omitempty"`
-	Reviewers []string   `yaml:"reviewers,omitempty"`
-	Comment   string     `yaml:"comment,omitempty"`
-	State     string     `yaml:"state,omitempty"`
-	Notify    string     `yaml:"notify,omitempty"`
-	Next      []string   `yaml:"next,omitempty"`
+	Type         ActionType   `yaml:"-"`
+	Run          string       `yaml:"run,omitempty"`
+	Labels       []string     `yaml:"labels,omitempty"`
+	Assignees    *Candidates  `yaml:"assignees,omitempty"`
+	Reviewers    *Candidates  `yaml:"reviewers,omitempty"`
+	Comment      string       `yaml:"comment,omitempty"`
+	State        string       `yaml:"state,omitempty"`
+	Notify       *Notify      `yaml:"notify,omitempty"`
+	Reaction     string       `yaml:"reaction,omitempty"`
+	Project      *Project     `yaml:"project,omitempty"`
+	CreateIssue  *CreateIssue `yaml:"create_issue,omitempty"`
+	UpdateBranch string       `yaml:"update_branch,omitempty"`
+	AutoMerge    string       `yaml:"auto_merge,omitempty"`
+	Next         []string     `yaml:"next,omitempty"`
+}
+
+// Reactions is the reaction types of GitHub
+// https://docs.github.com/en/rest/reference/reactions#reaction-types
+var Reactions = []string{"+1", "-1", "laugh", "confused", "heart", "hooray", "rocket", "eyes"}
+
+// CandidatesFromCodeOwners selects reviewers from the code owners of the changed files
+const CandidatesFromCodeOwners = "code_owners"
+
+// Candidates is a list of users of the `reviewers:` and `assignees:` actions.
+// It is written as a list of users or as a mapping of `users:` ( or `from:` ) and `exclude:`.
+type Candidates struct {
+	Users   []string `yaml:"users,omitempty"`
+	From    string   `yaml:"from,omitempty"`
+	Exclude *Exclude `yaml:"exclude,omitempty"`
+}
+
+// Exclude is rules to exclude users from the candidates before sampling
+type Exclude struct {
+	Approved bool     `yaml:"approved,omitempty"`
+	Assigned bool     `yaml:"assigned,omitempty"`
+	Bots     bool     `yaml:"bots,omitempty"`
+	Teams    []string `yaml:"teams,omitempty"`
+	If       string   `yaml:"if,omitempty"`
+}
+
+// Notify is a message of the `notify:` action.
+// It is written as a string or as a Block Kit layout ( `text:`, `blocks:` and `footer:` ).
+// Notifications with the same `digest:` key are aggregated into one message at the end of the session.
+type Notify struct {
+	Text   string        `yaml:"text,omitempty"`
+	Blocks []interface{} `yaml:"blocks,omitempty"`
+	Footer *bool         `yaml:"footer,omitempty"`
+	Digest string        `yaml:"digest,omitempty"`
+}
+
+// WithFooter returns whether to append the default footer to the blocks
+func (n *Notify) WithFooter() bool {
+	return n.Footer == nil || *n.Footer
+}
+
+type Project struct {
+	Number  int               `yaml:"number"`
+	Owner   string            `yaml:"owner,omitempty"`
+	Fields  map[string]string `yaml:"fields,omitempty"`
+	Archive bool              `yaml:"archive,omitempty"`
+}
+
+type CreateIssue struct {
+	Title      string   `yaml:"title"`
+	Body       string   `yaml:"body,omitempty"`
+	Labels     []string `yaml:"labels,omitempty"`
+	Assignees  []string `yaml:"assignees,omitempty"`
+	Repository string   `yaml:"repository,omitempty"`
 }
@@ -3,20 +3,39 @@ package task
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
+	"github.com/k1LoW/duration"
 	"github.com/k1LoW/ghdag/env"
 )
 
 type Task struct {
-	Id   string
-	If   string `yaml:"if,omitempty"`
-	Do   *Action
-	Ok   *Action `yaml:"ok,omitempty"`
-	Ng   *Action `yaml:"ng,omitempty"`
-	Env  env.Env `yaml:"env,omitempty"`
-	Name string  `yaml:"name,omitempty"`
+	Id    string
+	If    string `yaml:"if,omitempty"`
+	Do    *Action
+	Ok    *Action  `yaml:"ok,omitempty"`
+	Ng    *Action  `yaml:"ng,omitempty"`
+	Env   env.Env  `yaml:"env,omitempty"`
+	Name  string   `yaml:"name,omitempty"`
+	Needs []string `yaml:"needs,omitempty"`
+	// Timeout is a timeout of each action of the task
+	Timeout         string `yaml:"timeout,omitempty"`
+	ContinueOnError bool   `yaml:"continue_on_error,omitempty"`
+	OnError         string `yaml:"on_error,omitempty"`
+	// Priority is a priority of the task for each target ( higher first )
+	Priority int `yaml:"priority,omitempty"`
 }
 
+const (
+	// OnErrorNg performs the `ng:` action when the `do:` action fails ( default )
+	OnErrorNg = "ng"
+	// OnErrorSkip skips the task for the target when the `do:` action fails
+	OnErrorSkip = "skip"
+	// OnErrorAbort aborts the session when an action of the task fails
+	OnErrorAbort = "abort"
+)
+
 type Tasks []*Task
 
 func (tasks Tasks) Find(id string) (*Task, error) {
@@ -28,6 +47,49 @@ func (tasks Tasks) Find(id string) (*Task, error) {
 	return nil, fmt.Errorf("not found task: %s", id)
 }
 
+var codeOwnerRulesVariableRe = regexp.MustCompile(`\bcode_owner_rules_unapproved\b`)
+
+// UsesCodeOwnerRules returns whether the tasks refer to the CODEOWNERS rules of pull requests
+// ( `code_owner_rules_unapproved` in `if:` sections or `reviewers: { from: code_owners }` )
+func (tasks Tasks) UsesCodeOwnerRules() bool {
+	for _, t := range tasks {
+		for _, a := range []*Action{t.Do, t.Ok, t.Ng} {
+			if a != nil && a.Reviewers != nil && a.Reviewers.From == CandidatesFromCodeOwners {
+				return true
+			}
+		}
+	}
+	return tasks.usesVariable(codeOwnerRulesVariableRe)
+}
+
+var projectVariableRe = regexp.MustCompile(`\bproject_\w+`)
+
+// UsesProjectVariables returns whether the `if:` sections of the tasks refer to the project variables
+// ( `project_items` or `project_<field_name>` )
+func (tasks Tasks) UsesProjectVariables() bool {
+	return tasks.usesVariable(projectVariableRe)
+}
+
+// usesVariable returns whether the `if:` sections ( including `exclude.if:` ) of the tasks match the variable
+func (tasks Tasks) usesVariable(re *regexp.Regexp) bool {
+	for _, t := range tasks {
+		if re.MatchString(t.If) {
+			return true
+		}
+		for _, a := range []*Action{t.Do, t.Ok, t.Ng} {
+			if a == nil {
+				continue
+			}
+			for _, c := range []*Candidates{a.Assignees, a.Reviewers} {
+				if c != nil && c.Exclude != nil && re.MatchString(c.Exclude.If) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (tasks Tasks) MaxLengthID() int {
 	length := 0
 	for _, t := range tasks {
@@ -52,6 +114,22 @@ func (t *Task) CheckSyntax() (bool, []string) {
 		valid = false
 		errors = append(errors, fmt.Sprintf("%snot found `do:` action", prefix))
 	}
+	if t.Timeout != "" {
+		if _, err := duration.Parse(t.Timeout); err != nil {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `timeout:` (%s)", prefix, err))
+		}
+	}
+	switch t.OnError {
+	case "", OnErrorNg, OnErrorSkip, OnErrorAbort:
+	default:
+		valid = false
+		errors = append(errors, fmt.Sprintf("%sinvalid `on_error:` (%s)", prefix, t.OnError))
+	}
+	if t.ContinueOnError && t.OnError != "" {
+		valid = false
+		errors = append(errors, fmt.Sprintf("%s`continue_on_error:` and `on_error:` cannot be used together", prefix))
+	}
 	if t.Ok != nil {
 		v, e := t.CheckActionSyntax(t.Ok)
 		if !v {
@@ -81,21 +159,84 @@ func (t *Task) CheckActionSyntax(a *Action) (bool, []string) {
 		c++
 	}
 	as, _ := t.Env["GITHUB_ASSIGNEES"]
-	if len(a.Assignees) > 0 || (a.Assignees != nil && as != "") || (a.Assignees != nil && os.Getenv("GITHUB_ASSIGNEES") != "") {
+	if a.Assignees != nil && (len(a.Assignees.Users) > 0 || as != "" || os.Getenv("GITHUB_ASSIGNEES") != "") {
 		c++
 	}
+	if a.Assignees != nil && a.Assignees.From != "" {
+		valid = false
+		errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`assignees.from:` is not supported)", prefix, a.Type))
+	}
 	rs, _ := t.Env["GITHUB_REVIEWERS"]
-	if len(a.Reviewers) > 0 || (a.Reviewers != nil && rs != "") || (a.Reviewers != nil && os.Getenv("GITHUB_REVIEWERS") != "") {
+	if a.Reviewers != nil && (len(a.Reviewers.Users) > 0 || rs != "" || os.Getenv("GITHUB_REVIEWERS") != "" || a.Reviewers.From != "") {
 		c++
 	}
+	if a.Reviewers != nil && a.Reviewers.From != "" {
+		if a.Reviewers.From != CandidatesFromCodeOwners {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`reviewers.from:` supports only `%s`)", prefix, a.Type, CandidatesFromCodeOwners))
+		}
+		if len(a.Reviewers.Users) > 0 {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`reviewers.users:` and `reviewers.from:` cannot be used together)", prefix, a.Type))
+		}
+	}
 	if a.Comment != "" {
 		c++
 	}
 	if a.State != "" {
 		c++
 	}
-	if a.Notify != "" {
+	if a.Notify != nil {
+		c++
+		if a.Notify.Text == "" && len(a.Notify.Blocks) == 0 {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`notify.text:` or `notify.blocks:` is required)", prefix, a.Type))
+		}
+		if a.Notify.Digest != "" && a.Notify.Text == "" {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`notify.text:` is required for `notify.digest:`)", prefix, a.Type))
+		}
+		if a.Notify.Digest != "" && len(a.Notify.Blocks) > 0 {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`notify.blocks:` is not supported for `notify.digest:`)", prefix, a.Type))
+		}
+	}
+	if a.Reaction != "" {
+		c++
+		if !contains(Reactions, strings.Trim(a.Reaction, ":")) {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`reaction:` supports only %s)", prefix, a.Type, strings.Join(Reactions, ", ")))
+		}
+	}
+	if a.Project != nil {
+		c++
+		if a.Project.Number <= 0 {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`project.number:` is required)", prefix, a.Type))
+		}
+	}
+	if a.CreateIssue != nil {
+		c++
+		if a.CreateIssue.Title == "" {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`create_issue.title:` is required)", prefix, a.Type))
+		}
+	}
+	if a.UpdateBranch != "" {
+		c++
+		if a.UpdateBranch != "merge" && a.UpdateBranch != "rebase" {
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`update_branch:` supports only `merge` or `rebase`)", prefix, a.Type))
+		}
+	}
+	if a.AutoMerge != "" {
 		c++
+		switch a.AutoMerge {
+		case "merge", "squash", "rebase", "disable", "disabled":
+		default:
+			valid = false
+			errors = append(errors, fmt.Sprintf("%sinvalid `%s:` action (`auto_merge:` supports only `merge`, `squash`, `rebase` or `disable`)", prefix, a.Type))
+		}
 	}
 	if len(a.Next) > 0 {
 		c++
@@ -122,5 +263,76 @@ func (tasks Tasks) CheckSyntax() (bool, []string) {
 		}
 		ids[t.Id] = struct{}{}
 	}
+	for _, t := range tasks {
+		for _, id := range t.Needs {
+			if _, exist := ids[id]; !exist {
+				valid = false
+				errors = append(errors, fmt.Sprintf("[%s] not found task in `needs:`: %s", t.Id, id))
+				continue
+			}
+			// the task without `if:` and `needs:` is performed only via `next:` after all the other tasks,
+			// so the dependents would always see `skipped`
+			if nt, _ := tasks.Find(id); nt.If == "" && len(nt.Needs) == 0 {
+				valid = false
+				errors = append(errors, fmt.Sprintf("[%s] `needs:` cannot refer to the task performed only via `next:`: %s", t.Id, id))
+			}
+		}
+	}
+	if valid {
+		if _, err := tasks.Sorted(); err != nil {
+			valid = false
+			errors = append(errors, err.Error())
+		}
+	}
 	return valid, errors
 }
+
+// Sorted returns the tasks sorted topologically by `needs:`.
+// Among the tasks whose `needs:` are satisfied, the task with the highest `priority:` comes first,
+// and tasks with the same priority keep the order of the definition.
+func (tasks Tasks) Sorted() (Tasks, error) {
+	sorted := Tasks{}
+	done := map[string]struct{}{}
+	for len(sorted) < len(tasks) {
+		var next *Task
+		for _, t := range tasks {
+			if _, ok := done[t.Id]; ok {
+				continue
+			}
+			ready := true
+			for _, id := range t.Needs {
+				if _, ok := done[id]; !ok {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+			if next == nil || t.Priority > next.Priority {
+				next = t
+			}
+		}
+		if next == nil {
+			remain := []string{}
+			for _, t := range tasks {
+				if _, ok := done[t.Id]; !ok {
+					remain = append(remain, t.Id)
+				}
+			}
+			return nil, fmt.Errorf("circular or unresolvable `needs:` between tasks: %s", strings.Join(remain, ", "))
+		}
+		sorted = append(sorted, next)
+		done[next.Id] = struct{}{}
+	}
+	return sorted, nil
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
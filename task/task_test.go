@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/goccy/go-yaml"
+	"github.com/google/go-cmp/cmp"
 	"github.com/k1LoW/ghdag/env"
 )
 
@@ -28,6 +29,16 @@ do:
 		{[]byte(`
 id: task-id
 if: bug in labels
+do:
+  notify:
+    text: hello
+    digest: daily
+    blocks:
+      - type: divider
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: bug in labels
 do:
   assignees: [alice bob charlie]
   comment: hello
@@ -77,6 +88,155 @@ do:
 `), map[string]string{
 			"GITHUB_REVIEWERS": "alice bob charlie",
 		}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  reviewers:
+    users: [alice, bob, charlie]
+    exclude:
+      approved: true
+      bots: true
+      teams: [myorg/leads]
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  reviewers:
+    exclude:
+      approved: true
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  reviewers:
+    from: code_owners
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  reviewers:
+    from: code_owners
+    users: [alice]
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  assignees:
+    from: code_owners
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  project:
+    number: 1
+    fields:
+      Status: In review
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  project:
+    fields:
+      Status: In review
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  notify: hello
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  notify:
+    text: hello
+    blocks:
+      - type: section
+        text:
+          type: mrkdwn
+          text: hello
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  notify:
+    footer: false
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  run: make
+timeout: 10 min
+on_error: abort
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  run: make
+timeout: ten minutes
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  run: make
+on_error: retry
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request
+do:
+  run: make
+continue_on_error: true
+on_error: skip
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request && behind_base
+do:
+  update_branch: rebase
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request && behind_base
+do:
+  update_branch: squash
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_pull_request && is_approved
+do:
+  auto_merge: squash
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_pull_request && is_approved
+do:
+  auto_merge: squashh
+`), map[string]string{}, false},
+		{[]byte(`
+id: task-id
+if: is_issue
+do:
+  reaction: ':+1:'
+`), map[string]string{}, true},
+		{[]byte(`
+id: task-id
+if: is_issue
+do:
+  reaction: thumbsup
+`), map[string]string{}, false},
 	}
 	envCache := os.Environ()
 	for _, tt := range tests {
@@ -95,3 +255,159 @@ do:
 		}
 	}
 }
+
+func TestNotifyUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		in         []byte
+		wantText   string
+		wantBlocks int
+		wantFooter bool
+	}{
+		{[]byte(`notify: hello`), "hello", 0, true},
+		{[]byte(`
+notify:
+  text: hello
+  blocks:
+    - type: divider
+    - type: section
+      text:
+        type: mrkdwn
+        text: world
+  footer: false
+`), "hello", 2, false},
+	}
+	for _, tt := range tests {
+		a := &Action{}
+		if err := yaml.Unmarshal(tt.in, a); err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Notify.Text; got != tt.wantText {
+			t.Errorf("got %v\nwant %v", got, tt.wantText)
+		}
+		if got := len(a.Notify.Blocks); got != tt.wantBlocks {
+			t.Errorf("got %v\nwant %v", got, tt.wantBlocks)
+		}
+		if got := a.Notify.WithFooter(); got != tt.wantFooter {
+			t.Errorf("got %v\nwant %v", got, tt.wantFooter)
+		}
+	}
+}
+
+func TestTasksSorted(t *testing.T) {
+	tests := []struct {
+		tasks   Tasks
+		want    []string
+		wantErr bool
+	}{
+		{
+			Tasks{{Id: "a"}, {Id: "b"}, {Id: "c"}},
+			[]string{"a", "b", "c"},
+			false,
+		},
+		{
+			Tasks{{Id: "a", Needs: []string{"c"}}, {Id: "b"}, {Id: "c", Needs: []string{"b"}}},
+			[]string{"b", "c", "a"},
+			false,
+		},
+		{
+			Tasks{{Id: "a", Needs: []string{"b", "c"}}, {Id: "b", Needs: []string{"c"}}, {Id: "c"}, {Id: "d"}},
+			[]string{"c", "b", "a", "d"},
+			false,
+		},
+		{
+			Tasks{{Id: "a"}, {Id: "b", Priority: 10}, {Id: "c", Priority: -1}, {Id: "d", Priority: 10}},
+			[]string{"b", "d", "a", "c"},
+			false,
+		},
+		{
+			Tasks{{Id: "a", Priority: 10, Needs: []string{"c"}}, {Id: "b", Priority: 5}, {Id: "c"}},
+			[]string{"b", "c", "a"},
+			false,
+		},
+		{
+			Tasks{{Id: "a", Needs: []string{"b"}}, {Id: "b", Needs: []string{"a"}}, {Id: "c"}},
+			nil,
+			true,
+		},
+		{
+			Tasks{{Id: "a", Needs: []string{"a"}}},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.tasks.Sorted()
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+			continue
+		}
+		ids := []string{}
+		for _, t := range got {
+			ids = append(ids, t.Id)
+		}
+		if diff := cmp.Diff(ids, tt.want, nil); diff != "" {
+			t.Errorf("%s", diff)
+		}
+	}
+}
+
+func TestTasksCheckSyntaxWithNeeds(t *testing.T) {
+	do := &Action{Type: ActionTypeDo, Run: "echo"}
+	tests := []struct {
+		tasks  Tasks
+		wantOk bool
+	}{
+		{Tasks{{Id: "a", If: "true", Do: do}, {Id: "b", Do: do, Needs: []string{"a"}}}, true},
+		{Tasks{{Id: "a", If: "true", Do: do}, {Id: "b", Do: do, Needs: []string{"a"}}, {Id: "c", Do: do, Needs: []string{"b"}}}, true},
+		{Tasks{{Id: "a", If: "true", Do: do}, {Id: "b", Do: do, Needs: []string{"x"}}}, false},
+		{Tasks{{Id: "a", If: "true", Do: do, Needs: []string{"b"}}, {Id: "b", If: "true", Do: do, Needs: []string{"a"}}}, false},
+		{Tasks{{Id: "a", Do: do}, {Id: "b", Do: do, Needs: []string{"a"}}}, false},
+	}
+	for _, tt := range tests {
+		if ok, _ := tt.tasks.CheckSyntax(); ok != tt.wantOk {
+			t.Errorf("got %v\nwant %v", ok, tt.wantOk)
+		}
+	}
+}
+
+func TestTasksUsesCodeOwnerRules(t *testing.T) {
+	tests := []struct {
+		tasks Tasks
+		want  bool
+	}{
+		{Tasks{{Id: "a", If: "is_approved", Do: &Action{Labels: []string{"approved"}}}}, false},
+		{Tasks{{Id: "a", If: "len(code_owner_rules_unapproved) > 0", Do: &Action{Labels: []string{"approved"}}}}, true},
+		{Tasks{{Id: "a", If: "true", Do: &Action{Run: "echo ${GHDAG_TASK_CODE_OWNER_RULES_UNAPPROVED}"}}}, false},
+		{Tasks{{Id: "a", If: "true", Do: &Action{Reviewers: &Candidates{Users: []string{"alice"}, Exclude: &Exclude{If: "len(code_owner_rules_unapproved) == 0"}}}}}, true},
+		{Tasks{{Id: "a", If: "true", Do: &Action{Labels: []string{"approved"}}, Ok: &Action{Reviewers: &Candidates{From: CandidatesFromCodeOwners}}}}, true},
+		{Tasks{{Id: "a", If: "true", Do: &Action{Reviewers: &Candidates{Users: []string{"alice"}}}}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.tasks.UsesCodeOwnerRules(); got != tt.want {
+			t.Errorf("%v: got %v\nwant %v", tt.tasks[0], got, tt.want)
+		}
+	}
+}
+
+func TestTasksUsesProjectVariables(t *testing.T) {
+	tests := []struct {
+		tasks Tasks
+		want  bool
+	}{
+		{Tasks{{Id: "a", If: "is_issue", Do: &Action{Labels: []string{"todo"}}}}, false},
+		{Tasks{{Id: "a", If: `project_status == "Todo"`, Do: &Action{Labels: []string{"todo"}}}}, true},
+		{Tasks{{Id: "a", If: "len(project_items) == 0", Do: &Action{Labels: []string{"todo"}}}}, true},
+		{Tasks{{Id: "a", If: "true", Do: &Action{Reviewers: &Candidates{Users: []string{"alice"}, Exclude: &Exclude{If: `project_status == "Done"`}}}}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.tasks.UsesProjectVariables(); got != tt.want {
+			t.Errorf("%v: got %v\nwant %v", tt.tasks[0], got, tt.want)
+		}
+	}
+}
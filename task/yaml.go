@@ -7,13 +7,19 @@ import (
 
 func (t *Task) UnmarshalYAML(data []byte) error {
 	raw := &struct {
-		Id   string
-		If   string `yaml:"if,omitempty"`
-		Do   *Action
-		Ok   *Action `yaml:"ok,omitempty"`
-		Ng   *Action `yaml:"ng,omitempty"`
-		Env  env.Env `yaml:"env,omitempty"`
-		Name string  `yaml:"name,omitempty"`
+		Id    string
+		If    string `yaml:"if,omitempty"`
+		Do    *Action
+		Ok    *Action  `yaml:"ok,omitempty"`
+		Ng    *Action  `yaml:"ng,omitempty"`
+		Env   env.Env  `yaml:"env,omitempty"`
+		Name  string   `yaml:"name,omitempty"`
+		Needs []string `yaml:"needs,omitempty"`
+
+		Timeout         string `yaml:"timeout,omitempty"`
+		ContinueOnError bool   `yaml:"continue_on_error,omitempty"`
+		OnError         string `yaml:"on_error,omitempty"`
+		Priority        int    `yaml:"priority,omitempty"`
 	}{}
 	if err := yaml.Unmarshal(data, raw); err != nil {
 		return err
@@ -34,6 +40,53 @@ func (t *Task) UnmarshalYAML(data []byte) error {
 	}
 	t.Env = raw.Env
 	t.Name = raw.Name
+	t.Needs = raw.Needs
+	t.Timeout = raw.Timeout
+	t.ContinueOnError = raw.ContinueOnError
+	t.OnError = raw.OnError
+	t.Priority = raw.Priority
 
 	return nil
 }
+
+func (n *Notify) UnmarshalYAML(data []byte) error {
+	var text string
+	if err := yaml.Unmarshal(data, &text); err == nil {
+		n.Text = text
+		return nil
+	}
+	raw := &struct {
+		Text   string        `yaml:"text,omitempty"`
+		Blocks []interface{} `yaml:"blocks,omitempty"`
+		Footer *bool         `yaml:"footer,omitempty"`
+		Digest string        `yaml:"digest,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, raw); err != nil {
+		return err
+	}
+	n.Text = raw.Text
+	n.Blocks = raw.Blocks
+	n.Footer = raw.Footer
+	n.Digest = raw.Digest
+	return nil
+}
+
+func (c *Candidates) UnmarshalYAML(data []byte) error {
+	var users []string
+	if err := yaml.Unmarshal(data, &users); err == nil {
+		c.Users = users
+		return nil
+	}
+	raw := &struct {
+		Users   []string `yaml:"users,omitempty"`
+		From    string   `yaml:"from,omitempty"`
+		Exclude *Exclude `yaml:"exclude,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, raw); err != nil {
+		return err
+	}
+	c.Users = raw.Users
+	c.From = raw.From
+	c.Exclude = raw.Exclude
+	return nil
+}